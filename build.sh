@@ -1,11 +1,24 @@
 #!/bin/bash -e
 
 # echo "正在生成64位的Proxy"
-# GOOS=windows GOARCH=amd64 go build  -o proxy64.exe proxy.go
+# GOOS=windows GOARCH=amd64 go build  -o proxy64.exe walkr
 
+# 先source init_env.sh, go-logging要是锁定的版本
+for dir in ${GOPATH//:/ }; do
+  if [ -d $dir/src/github.com/op/go-logging/.git ]; then
+    rev=$(git -C $dir/src/github.com/op/go-logging rev-parse HEAD)
+    if [ "$rev" != "970db520ece77730c7e4724c61121037378659d9" ]; then
+      echo "go-logging的版本是$rev, 先source init_env.sh"
+      exit 1
+    fi
+  fi
+done
+
+# 打包出去的程序不带参数直接启动代理
+# 交叉编译没有cgo, 不带-tags sqlite, walkr game export用不了; 要用的话在本机编译: go build -tags sqlite walkr
 echo "正在生成32位的Proxy"
-GOOS=windows GOARCH=386 go build -ldflags "-s -w"  -o proxy32.exe src/proxy.go
-GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w"  -o proxy src/proxy.go
+GOOS=windows GOARCH=386 go build -ldflags "-s -w -X main.defaultCommand=proxy"  -o proxy32.exe walkr
+GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w -X main.defaultCommand=proxy"  -o proxy walkr
 if which upx 2>/dev/null; then
   echo "正在使用UPX压缩"
 	upx proxy32.exe
@@ -8,4 +8,12 @@ then
     export GOPATH=$GOPATH:$DIR
     echo "Set GOPATH=${GOPATH}"
 fi
-echo Finished!
\ No newline at end of file
+# go-logging 2.0之后Info和Infof含义不同, logger包用的是Infof, 锁定在这个版本
+GO_LOGGING_REV=970db520ece77730c7e4724c61121037378659d9
+GO_LOGGING_DIR=${DIR}/src/github.com/op/go-logging
+if [ ! -d ${GO_LOGGING_DIR} ]
+then
+    git clone -q https://github.com/op/go-logging ${GO_LOGGING_DIR}
+fi
+git -C ${GO_LOGGING_DIR} checkout -q ${GO_LOGGING_REV} && echo "go-logging: ${GO_LOGGING_REV}"
+echo Finished!
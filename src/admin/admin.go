@@ -0,0 +1,200 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 管理接口看到的账号信息
+type Account struct {
+	Name         string    `json:"name"`
+	PlayerId     int       `json:"player_id"`
+	EpicHelper   bool      `json:"epic_helper"`
+	Round        int       `json:"round"`
+	State        string    `json:"state"`
+	Paused       bool      `json:"paused"`
+	FleetId      int       `json:"fleet_id,omitempty"`
+	FleetName    string    `json:"fleet_name,omitempty"`
+	Captain      string    `json:"captain,omitempty"`
+	LeaveAt      time.Time `json:"leave_at,omitempty"`
+	NextTask     string    `json:"next_task,omitempty"`
+	NextRun      time.Time `json:"next_run,omitempty"`
+	WaitDuration string    `json:"wait_duration"`
+	Quota        Quota     `json:"quota"`
+	LastError    string    `json:"last_error,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 帮飞过程中的事件, 比如加入离开舰队, 出错等
+type Event struct {
+	Time    time.Time `json:"time"`
+	Account string    `json:"account"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+type Quota struct {
+	MaxFleetsPerHour      int `json:"max_fleets_per_hour"`
+	MaxFleetsPerDay       int `json:"max_fleets_per_day"`
+	MaxFleetMinutesPerDay int `json:"max_fleet_minutes_per_day"`
+}
+
+// 运行时可以修改的设置, 没有填写的字段保持不变
+type Settings struct {
+	WaitDuration          string `json:"wait_duration,omitempty"`
+	MaxFleetsPerHour      *int   `json:"max_fleets_per_hour,omitempty"`
+	MaxFleetsPerDay       *int   `json:"max_fleets_per_day,omitempty"`
+	MaxFleetMinutesPerDay *int   `json:"max_fleet_minutes_per_day,omitempty"`
+}
+
+var ErrNotFound = errors.New("账号不存在")
+
+type Controller interface {
+	Accounts() []Account
+	Events(n int) ([]Event, error)
+	Pause(playerId int) error
+	Resume(playerId int) error
+	Leave(playerId int) error
+	CheckFriends(playerId int) error
+	Update(playerId int, settings Settings) error
+}
+
+// 只允许监听在本机地址上, 所有请求都需要带上Token
+//
+//	GET  /accounts
+//	GET  /events?n=50
+//	GET  /accounts/{playerId}
+//	POST /accounts/{playerId}/pause
+//	POST /accounts/{playerId}/resume
+//	POST /accounts/{playerId}/leave
+//	POST /accounts/{playerId}/friends
+//	POST /accounts/{playerId}/settings   {"wait_duration": "3m", "max_fleets_per_day": 20}
+type Server struct {
+	token      string
+	controller Controller
+}
+
+func Listen(addr string, token string, controller Controller) error {
+	if token == "" {
+		return errors.New("管理接口需要设置Token")
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("管理接口只能监听在本机地址上: %v", addr)
+	}
+
+	return http.ListenAndServe(addr, &Server{token: token, controller: controller})
+}
+
+func (this *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !this.authorized(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("Token不正确"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "events" {
+		n, err := strconv.Atoi(r.FormValue("n"))
+		if err != nil || n <= 0 {
+			n = 50
+		}
+		events, err := this.controller.Events(n)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, events)
+		return
+	}
+	if parts[0] != "accounts" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, errors.New("接口不存在"))
+		return
+	}
+	if len(parts) == 1 {
+		if r.Method != "GET" {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("只支持GET"))
+			return
+		}
+		writeJSON(w, http.StatusOK, this.controller.Accounts())
+		return
+	}
+
+	playerId, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("PlayerId有问题"))
+		return
+	}
+	if len(parts) == 2 {
+		for _, account := range this.controller.Accounts() {
+			if account.PlayerId == playerId {
+				writeJSON(w, http.StatusOK, account)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, ErrNotFound)
+		return
+	}
+
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("只支持POST"))
+		return
+	}
+	switch parts[2] {
+	case "pause":
+		err = this.controller.Pause(playerId)
+	case "resume":
+		err = this.controller.Resume(playerId)
+	case "leave":
+		err = this.controller.Leave(playerId)
+	case "friends":
+		err = this.controller.CheckFriends(playerId)
+	case "settings":
+		var settings Settings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("解析设置失败: %v", err))
+			return
+		}
+		err = this.controller.Update(playerId, settings)
+	default:
+		writeError(w, http.StatusNotFound, errors.New("接口不存在"))
+		return
+	}
+
+	if err == ErrNotFound {
+		writeError(w, http.StatusNotFound, err)
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+	} else {
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	}
+}
+
+// 支持 "Authorization: Bearer <token>" 和 "X-Admin-Token: <token>"
+func (this *Server) authorized(r *http.Request) bool {
+	token := r.Header.Get("X-Admin-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(this.token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]interface{}{"success": false, "error": err.Error()})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"logger"
+	"metrics"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 所有请求共用, 顺便记录每个接口的耗时和返回状态; 服务器没有响应的话不能一直卡着Worker
+var HttpClient = &http.Client{Transport: &metrics.Transport{}, Timeout: 30 * time.Second}
+
+// 配置文件里的[[PlayerInfo]], 所有子命令共用
+type PlayerInfo struct {
+	Name            string `json:"-"`
+	AuthToken       string `json:"auth_token"`
+	ClientVersion   string `json:"client_version"`
+	Platform        string `json:"platform"`
+	Locale          string `json:"locale"`
+	Cookie          string `json:"-"`
+	ConvertedEnergy int    `json:"-"`
+	EpicHelper      bool   `json:"-"`
+
+	// 活跃时间, 不在活跃时间内不再加入新的舰队
+	TimeZone      string   `json:"-"`
+	ActiveWindows []string `json:"-"`
+	BlackoutDates []string `json:"-"`
+
+	// 帮飞配额, 0表示不限制, 每天在QuotaResetAt(默认00:00)重置
+	MaxFleetsPerHour      int    `json:"-"`
+	MaxFleetsPerDay       int    `json:"-"`
+	MaxFleetMinutesPerDay int    `json:"-"`
+	QuotaResetAt          string `json:"-"`
+}
+
+func (this *PlayerInfo) PlayerId() int {
+	playerId, _ := strconv.Atoi(strings.Split(this.AuthToken, ":")[0])
+	return playerId
+}
+
+// 凭证的指纹, 用来判断配置文件里的凭证有没有更新
+func (this *PlayerInfo) Fingerprint() string {
+	md5h := md5.New()
+	md5h.Write([]byte(this.AuthToken + "\n" + this.Cookie))
+	return hex.EncodeToString(md5h.Sum([]byte("")))
+}
+
+// 日志里带上账号名和PlayerId
+func (this *PlayerInfo) Log() *logger.Logger {
+	return logger.New("walkr", logger.Fields{Account: this.Name, PlayerId: this.PlayerId()})
+}
+
+type BoolResponse struct {
+	Success bool `json:"success"`
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"metrics"
+	"net/http"
+	"net/url"
+	"utils"
+)
+
+// 4. 好友申请
+type ConfirmFriendRequest struct {
+	AuthToken     string `json:"auth_token"`
+	UserId        int    `json:"user_id"`
+	ClientVersion string `json:"client_version"`
+	Platform      string `json:"platform"`
+}
+
+// 好友申请
+type NewFriendListResponse struct {
+	Data []Friend `json:"data"`
+}
+type Friend struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func RequestNewFriendList(playerInfo PlayerInfo) (*http.Response, error) {
+	playerInfo.Log().Debug("查看是否有好友申请")
+
+	client := HttpClient
+	v := url.Values{}
+	v.Add("platform", playerInfo.Platform)
+	v.Add("auth_token", playerInfo.AuthToken)
+	v.Add("client_version", playerInfo.ClientVersion)
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/users/friend_invitations?%v", v.Encode())
+
+	req, err := utils.GenerateWalkrRequest(host, "GET", playerInfo.Cookie, nil)
+	if req == nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+
+}
+
+// 通过所有的好友申请, 返回好友申请列表接口的状态码, 请求失败的话是0; 有新的申请返回true
+func CheckFriendInvitation(playerInfo PlayerInfo) (int, bool) {
+	resp, err := RequestNewFriendList(playerInfo)
+	if err != nil {
+		playerInfo.Log().Error("获取好友申请失败: %v", err)
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		playerInfo.Log().Error("获取好友申请失败: %v", resp.Status)
+		return resp.StatusCode, false
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		playerInfo.Log().Error("读取返回数据失败: %v", err)
+		return resp.StatusCode, false
+	}
+
+	var records NewFriendListResponse
+	if err := json.Unmarshal([]byte(body), &records); err != nil {
+		playerInfo.Log().Error("解析好友列表数据失败: %v", err)
+		return resp.StatusCode, false
+	}
+
+	if len(records.Data) == 0 {
+		playerInfo.Log().Debug("没有新的好友申请")
+		return resp.StatusCode, false
+	}
+
+	for _, friend := range records.Data {
+		playerInfo.Log().Debug("新的好友申请['%v':%v]", friend.Name, friend.Id)
+		if ConfirmFriend(playerInfo, friend.Id) == true {
+			playerInfo.Log().Debug("添加好友['%v':%v]成功", friend.Name, friend.Id)
+			metrics.FriendConfirmation.Inc(playerInfo.Name, "success")
+		} else {
+			playerInfo.Log().Error("添加好友['%v':%v]失败", friend.Name, friend.Id)
+			metrics.FriendConfirmation.Inc(playerInfo.Name, "failure")
+		}
+	}
+
+	return resp.StatusCode, true
+}
+
+func ConfirmFriend(playerInfo PlayerInfo, friendId int) bool {
+	client := HttpClient
+
+	confirmFriendRequestJson := ConfirmFriendRequest{
+		AuthToken:     playerInfo.AuthToken,
+		ClientVersion: playerInfo.ClientVersion,
+		Platform:      playerInfo.Platform,
+		UserId:        friendId,
+	}
+	b, err := json.Marshal(confirmFriendRequestJson)
+	if err != nil {
+		playerInfo.Log().Error("Json Marshal error for %v", err)
+		return false
+	}
+
+	host := "https://universe.walkrgame.com/api/v1/users/confirm_friend"
+	req, err := utils.GenerateWalkrRequest(host, "POST", playerInfo.Cookie, bytes.NewBuffer([]byte(b)))
+	if err != nil {
+		return false
+	}
+
+	if resp, err := client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			playerInfo.Log().Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record BoolResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			playerInfo.Log().Error("通过好友失败: %v", err)
+			return false
+		}
+
+		return record.Success
+	} else {
+		playerInfo.Log().Error("请求添加用户失败: %v", err)
+
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"api"
+	"fmt"
+	"logger"
+	"notify"
+	"os"
+	"secret"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// -c 指定的配置文件, 所有子命令共用
+type Config struct {
+	PlayerInfo []api.PlayerInfo
+	Webhook    notify.Config
+	Vault      secret.Config
+	Log        logger.Config
+}
+
+// 读取配置文件, 把凭证的引用替换成真正的凭证, 并且在日志里隐藏
+func Load(path string) (Config, error) {
+	var config Config
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		return config, err
+	}
+
+	var vault *secret.Vault
+	for i := range config.PlayerInfo {
+		info := &config.PlayerInfo[i]
+		for _, field := range []*string{&info.AuthToken, &info.Cookie} {
+			if strings.HasPrefix(*field, secret.PREFIX_VAULT) && vault == nil {
+				v, err := secret.Open(config.Vault, os.Getenv(secret.PassphraseEnv))
+				if err != nil {
+					return config, err
+				}
+				vault = v
+			}
+			value, err := secret.Resolve(*field, vault)
+			if err != nil {
+				return config, fmt.Errorf("「%v」的凭证有问题: %v", info.Name, err)
+			}
+			*field = value
+		}
+
+		logger.AddSecret(info.AuthToken)
+		logger.AddSecret(info.Cookie)
+		for _, part := range strings.Split(info.Cookie, ";") {
+			if kv := strings.SplitN(strings.TrimSpace(part), "=", 2); len(kv) == 2 {
+				logger.AddSecret(kv[1])
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// 只读取配置文件里的[Vault], 不解析凭证; 配置文件不存在的话返回空的配置
+func LoadVault(path string) (secret.Config, error) {
+	var config struct {
+		Vault secret.Config
+	}
+	if _, err := toml.DecodeFile(path, &config); err != nil && !os.IsNotExist(err) {
+		return config.Vault, err
+	}
+	return config.Vault, nil
+}
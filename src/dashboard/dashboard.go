@@ -0,0 +1,211 @@
+package dashboard
+
+import (
+	"admin"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"status"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 账号状态的来源, 可以是运行中的管理接口, 也可以直接读Redis
+type Source interface {
+	Accounts() ([]admin.Account, error)
+	Events(n int) ([]admin.Event, error)
+}
+
+type RedisSource struct {
+	Redis *goredis.Client
+}
+
+func (this *RedisSource) Accounts() ([]admin.Account, error) {
+	return status.LoadAccounts(this.Redis)
+}
+
+func (this *RedisSource) Events(n int) ([]admin.Event, error) {
+	return status.LoadEvents(this.Redis, n)
+}
+
+type AdminSource struct {
+	URL   string
+	Token string
+}
+
+func (this *AdminSource) Accounts() ([]admin.Account, error) {
+	accounts := []admin.Account{}
+	err := this.get("/accounts", &accounts)
+	return accounts, err
+}
+
+func (this *AdminSource) Events(n int) ([]admin.Event, error) {
+	events := []admin.Event{}
+	err := this.get(fmt.Sprintf("/events?n=%v", n), &events)
+	return events, err
+}
+
+func (this *AdminSource) get(path string, value interface{}) error {
+	req, err := http.NewRequest("GET", strings.TrimRight(this.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+this.Token)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("管理接口返回%v", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+// 每隔interval刷新一次, 按Ctrl-C退出
+func Run(source Source, interval time.Duration, eventCount int) {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	defer signal.Stop(quit)
+
+	// 隐藏光标, 退出的时候恢复
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h\n")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		buf := &bytes.Buffer{}
+		Render(buf, source, eventCount, time.Now())
+		fmt.Print("\033[H\033[2J")
+		os.Stdout.Write(buf.Bytes())
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func Render(w io.Writer, source Source, eventCount int, now time.Time) {
+	fmt.Fprintf(w, "Walkr帮飞状态  %v  (Ctrl-C退出)\n\n", now.Format("2006-01-02 15:04:05"))
+
+	accounts, err := source.Accounts()
+	if err != nil {
+		fmt.Fprintf(w, "读取账号状态失败: %v\n", err)
+	} else {
+		rows := [][]string{{"账号", "PlayerId", "轮数", "状态", "舰队", "舰长", "离队倒计时", "最近错误"}}
+		for _, account := range accounts {
+			fleet, leaveIn := "", ""
+			if account.FleetId != 0 {
+				fleet = fmt.Sprintf("%v:%v", account.FleetName, account.FleetId)
+			}
+			if !account.LeaveAt.IsZero() {
+				leaveIn = formatDuration(account.LeaveAt.Sub(now))
+			}
+			rows = append(rows, []string{
+				account.Name,
+				fmt.Sprintf("%v", account.PlayerId),
+				fmt.Sprintf("%v", account.Round),
+				stateText(account),
+				fleet,
+				account.Captain,
+				leaveIn,
+				account.LastError,
+			})
+		}
+		writeTable(w, rows)
+	}
+
+	fmt.Fprintf(w, "\n最近事件\n%v\n", strings.Repeat("─", 60))
+	events, err := source.Events(eventCount)
+	if err != nil {
+		fmt.Fprintf(w, "读取事件失败: %v\n", err)
+		return
+	}
+	for _, event := range events {
+		fmt.Fprintf(w, "%v %-7v 「%v」%v\n", event.Time.Local().Format("15:04:05"), event.Level, event.Account, event.Message)
+	}
+}
+
+func stateText(account admin.Account) string {
+	text := map[string]string{"idle": "空闲", "in_fleet": "舰队中", "paused": "暂停", "suspended": "凭证失效"}[account.State]
+	if text == "" {
+		text = account.State
+	}
+	if account.Paused && account.State != "paused" {
+		text += "(待暂停)"
+	}
+
+	return text
+}
+
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "即将离队"
+	}
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// 中文占两个字符宽度, 不能直接用tabwriter对齐
+func writeTable(w io.Writer, rows [][]string) {
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if width := displayWidth(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	for _, row := range rows {
+		line := ""
+		for i, cell := range row {
+			line += cell
+			if i < len(row)-1 {
+				line += strings.Repeat(" ", widths[i]-displayWidth(cell)+2)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+func displayWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if isWide(r) {
+			width += 2
+		} else {
+			width += 1
+		}
+	}
+
+	return width
+}
+
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) ||
+		(r >= 0x2E80 && r <= 0xA4CF) ||
+		(r >= 0xAC00 && r <= 0xD7A3) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0xFE30 && r <= 0xFE4F) ||
+		(r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6)
+}
@@ -0,0 +1,328 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// 两份游戏数据之间的变化, Before是旧的, After是新的
+type Difference struct {
+	Resources    []ResourceDelta `json:"resources"`
+	Moved        []ColonyMove    `json:"moved"`
+	Added        []ColonyRef     `json:"added"`
+	Removed      []ColonyRef     `json:"removed"`
+	Releveled    []ColonyLevel   `json:"releveled"`
+	Satellites   []SatelliteMove `json:"satellites"`
+	Achievements []string        `json:"achievements"`
+	Missions     []string        `json:"missions"`
+}
+
+type ResourceDelta struct {
+	Name   string `json:"name"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+	Delta  int64  `json:"delta"`
+}
+
+type ColonyRef struct {
+	Identifier  string `json:"identifier"`
+	ColonyOrder int    `json:"colony_order"`
+	ColonyType  string `json:"colony_type"`
+	Level       int    `json:"level"`
+}
+
+type ColonyMove struct {
+	Identifier string  `json:"identifier"`
+	FromOrder  int     `json:"from_order"`
+	ToOrder    int     `json:"to_order"`
+	FromX      float64 `json:"from_x"`
+	FromY      float64 `json:"from_y"`
+	ToX        float64 `json:"to_x"`
+	ToY        float64 `json:"to_y"`
+}
+
+type ColonyLevel struct {
+	Identifier  string `json:"identifier"`
+	ColonyOrder int    `json:"colony_order"`
+	From        int    `json:"from"`
+	To          int    `json:"to"`
+}
+
+// 卫星所在的星球, 没有放置的话是nil; Added和Removed表示只在新的或者旧的satellites里
+type SatelliteMove struct {
+	Identifier string     `json:"identifier"`
+	From       *ColonyRef `json:"from"`
+	To         *ColonyRef `json:"to"`
+	Added      bool       `json:"added"`
+	Removed    bool       `json:"removed"`
+}
+
+func Diff(before, after *GameData) Difference {
+	diff := Difference{
+		Resources: []ResourceDelta{
+			newResourceDelta("coins", before.Coins, after.Coins),
+			newResourceDelta("cubes", int64(before.Cubes), int64(after.Cubes)),
+			newResourceDelta("energy", int64(before.Energy), int64(after.Energy)),
+			newResourceDelta("food", int64(before.Food), int64(after.Food)),
+			newResourceDelta("population", int64(before.Population), int64(after.Population)),
+			newResourceDelta("level", int64(before.Level), int64(after.Level)),
+		},
+		Moved:        []ColonyMove{},
+		Added:        []ColonyRef{},
+		Removed:      []ColonyRef{},
+		Releveled:    []ColonyLevel{},
+		Satellites:   []SatelliteMove{},
+		Achievements: []string{},
+		Missions:     []string{},
+	}
+
+	pairs, removed, added := matchColonies(before.Colonies, after.Colonies)
+	for _, colony := range removed {
+		diff.Removed = append(diff.Removed, newColonyRef(colony))
+	}
+	for _, colony := range added {
+		diff.Added = append(diff.Added, newColonyRef(colony))
+	}
+	for _, pair := range pairs {
+		a, b := pair[0], pair[1]
+		if a.ColonyOrder != b.ColonyOrder || a.OffsetX != b.OffsetX || a.OffsetY != b.OffsetY {
+			diff.Moved = append(diff.Moved, ColonyMove{
+				Identifier: a.Identifier,
+				FromOrder:  a.ColonyOrder,
+				ToOrder:    b.ColonyOrder,
+				FromX:      a.OffsetX,
+				FromY:      a.OffsetY,
+				ToX:        b.OffsetX,
+				ToY:        b.OffsetY,
+			})
+		}
+		if a.Level != b.Level {
+			diff.Releveled = append(diff.Releveled, ColonyLevel{Identifier: b.Identifier, ColonyOrder: b.ColonyOrder, From: a.Level, To: b.Level})
+		}
+	}
+	sort.Slice(diff.Moved, func(i, j int) bool { return diff.Moved[i].ToOrder < diff.Moved[j].ToOrder })
+	sort.Slice(diff.Releveled, func(i, j int) bool { return diff.Releveled[i].ColonyOrder < diff.Releveled[j].ColonyOrder })
+
+	// 卫星按所在星球的名字和顺序比较, 星球移动了卫星跟着走不算重新分配
+	beforeSatellites, afterSatellites := satelliteColonies(before), satelliteColonies(after)
+	moved := make(map[*Colony]*Colony)
+	for _, pair := range pairs {
+		moved[pair[0]] = pair[1]
+	}
+	beforeListed, afterListed := satelliteIdentifiers(before), satelliteIdentifiers(after)
+	for _, identifier := range satelliteUnion(before, after) {
+		from, to := beforeSatellites[identifier], afterSatellites[identifier]
+		added, removed := !beforeListed[identifier] && afterListed[identifier], beforeListed[identifier] && !afterListed[identifier]
+		if !added && !removed {
+			if from != nil && to != nil && moved[from] == to {
+				continue
+			}
+			if from == nil && to == nil {
+				continue
+			}
+		}
+		diff.Satellites = append(diff.Satellites, SatelliteMove{
+			Identifier: identifier,
+			From:       colonyRef(from),
+			To:         colonyRef(to),
+			Added:      added,
+			Removed:    removed,
+		})
+	}
+
+	completed := make(map[string]bool)
+	for _, achievement := range before.Achievements {
+		completed[achievement.Identifier] = achievement.Completed
+	}
+	for _, achievement := range after.Achievements {
+		if achievement.Completed && !completed[achievement.Identifier] {
+			diff.Achievements = append(diff.Achievements, achievement.Identifier)
+		}
+	}
+
+	completed = make(map[string]bool)
+	for _, mission := range before.Missions {
+		completed[mission.Identifier] = mission.Completed
+	}
+	for _, mission := range after.Missions {
+		if mission.Completed && !completed[mission.Identifier] {
+			diff.Missions = append(diff.Missions, mission.Identifier)
+		}
+	}
+
+	return diff
+}
+
+// 复制器的名字会重复, 先匹配名字和顺序都一样的, 剩下的同名星球按顺序一一对应
+func matchColonies(before, after []Colony) (pairs [][2]*Colony, removed, added []*Colony) {
+	used := make(map[*Colony]bool)
+	byKey := make(map[string]*Colony)
+	for i := range after {
+		byKey[fmt.Sprintf("%v#%v", after[i].Identifier, after[i].ColonyOrder)] = &after[i]
+	}
+
+	rest := []*Colony{}
+	for i := range before {
+		if colony, ok := byKey[fmt.Sprintf("%v#%v", before[i].Identifier, before[i].ColonyOrder)]; ok {
+			pairs = append(pairs, [2]*Colony{&before[i], colony})
+			used[colony] = true
+		} else {
+			rest = append(rest, &before[i])
+		}
+	}
+
+	byIdentifier := make(map[string][]*Colony)
+	for i := range after {
+		if !used[&after[i]] {
+			byIdentifier[after[i].Identifier] = append(byIdentifier[after[i].Identifier], &after[i])
+		}
+	}
+	for _, colony := range rest {
+		candidates := byIdentifier[colony.Identifier]
+		if len(candidates) == 0 {
+			removed = append(removed, colony)
+			continue
+		}
+		pairs = append(pairs, [2]*Colony{colony, candidates[0]})
+		used[candidates[0]] = true
+		byIdentifier[colony.Identifier] = candidates[1:]
+	}
+
+	for i := range after {
+		if !used[&after[i]] {
+			added = append(added, &after[i])
+		}
+	}
+	return pairs, removed, added
+}
+
+func satelliteColonies(data *GameData) map[string]*Colony {
+	colonies := make(map[string]*Colony)
+	for i := range data.Colonies {
+		for _, satellite := range data.Colonies[i].Satellites {
+			colonies[satellite.Identifier] = &data.Colonies[i]
+		}
+	}
+	return colonies
+}
+
+func satelliteIdentifiers(data *GameData) map[string]bool {
+	identifiers := make(map[string]bool)
+	for _, satellite := range data.Satellites {
+		identifiers[satellite.Identifier] = true
+	}
+	return identifiers
+}
+
+// 两边satellites和星球上的卫星的并集, 先按新的顺序, 再是只在旧的里面的
+func satelliteUnion(before, after *GameData) []string {
+	identifiers := []string{}
+	seen := make(map[string]bool)
+	add := func(identifier string) {
+		if !seen[identifier] {
+			seen[identifier] = true
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	for _, data := range []*GameData{after, before} {
+		for _, satellite := range data.Satellites {
+			add(satellite.Identifier)
+		}
+	}
+	for _, data := range []*GameData{after, before} {
+		for _, colony := range data.Colonies {
+			for _, satellite := range colony.Satellites {
+				add(satellite.Identifier)
+			}
+		}
+	}
+	return identifiers
+}
+
+func newResourceDelta(name string, before, after int64) ResourceDelta {
+	return ResourceDelta{Name: name, Before: before, After: after, Delta: after - before}
+}
+
+func newColonyRef(colony *Colony) ColonyRef {
+	return ColonyRef{Identifier: colony.Identifier, ColonyOrder: colony.ColonyOrder, ColonyType: colony.ColonyType, Level: colony.Level}
+}
+
+func colonyRef(colony *Colony) *ColonyRef {
+	if colony == nil {
+		return nil
+	}
+	ref := newColonyRef(colony)
+	return &ref
+}
+
+func (this ColonyRef) String() string {
+	return fmt.Sprintf("%v#%v", this.Identifier, this.ColonyOrder)
+}
+
+func WriteDiff(w io.Writer, format string, diff Difference) error {
+	switch format {
+	case "summary":
+		return WriteDiffSummary(w, diff)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(diff)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
+
+func WriteDiffSummary(w io.Writer, diff Difference) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "资源\t之前\t之后\t变化")
+	for _, resource := range diff.Resources {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%+d\n", resource.Name, resource.Before, resource.After, resource.Delta)
+	}
+
+	fmt.Fprintf(tw, "\n移动的星球(%v个)\n", len(diff.Moved))
+	for _, move := range diff.Moved {
+		fmt.Fprintf(tw, "  %v\t#%v -> #%v\t(%v, %v) -> (%v, %v)\n", move.Identifier, move.FromOrder, move.ToOrder, move.FromX, move.FromY, move.ToX, move.ToY)
+	}
+	fmt.Fprintf(tw, "\n增加的星球(%v个)\n", len(diff.Added))
+	for _, colony := range diff.Added {
+		fmt.Fprintf(tw, "  %v\t%v\tL%v\n", colony, colony.ColonyType, colony.Level)
+	}
+	fmt.Fprintf(tw, "\n删除的星球(%v个)\n", len(diff.Removed))
+	for _, colony := range diff.Removed {
+		fmt.Fprintf(tw, "  %v\t%v\tL%v\n", colony, colony.ColonyType, colony.Level)
+	}
+	fmt.Fprintf(tw, "\n等级变化的星球(%v个)\n", len(diff.Releveled))
+	for _, level := range diff.Releveled {
+		fmt.Fprintf(tw, "  %v#%v\tL%v -> L%v\n", level.Identifier, level.ColonyOrder, level.From, level.To)
+	}
+	fmt.Fprintf(tw, "\n重新分配的卫星(%v个)\n", len(diff.Satellites))
+	for _, satellite := range diff.Satellites {
+		note := ""
+		if satellite.Added {
+			note = "新增"
+		} else if satellite.Removed {
+			note = "删除"
+		}
+		fmt.Fprintf(tw, "  %v\t%v -> %v\t%v\n", satellite.Identifier, formatColonyRef(satellite.From), formatColonyRef(satellite.To), note)
+	}
+	fmt.Fprintf(tw, "\n新完成的成就(%v个)\n", len(diff.Achievements))
+	for _, identifier := range diff.Achievements {
+		fmt.Fprintf(tw, "  %v\n", identifier)
+	}
+	fmt.Fprintf(tw, "\n新完成的任务(%v个)\n", len(diff.Missions))
+	for _, identifier := range diff.Missions {
+		fmt.Fprintf(tw, "  %v\n", identifier)
+	}
+
+	return tw.Flush()
+}
+
+func formatColonyRef(colony *ColonyRef) string {
+	if colony == nil {
+		return "未放置"
+	}
+	return colony.String()
+}
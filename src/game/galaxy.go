@@ -0,0 +1,207 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// 星系图: 横坐标是OffsetX(-1到1), 纵坐标是OffsetY, 越往上越远, 和游戏里一样
+//
+// 可以同时画几份游戏数据, 并排显示, 纵坐标的范围是一样的, 方便对比调整顺序前后的样子
+
+const (
+	SVG_SCALE_X     = 100.0
+	SVG_SCALE_Y     = 24.0
+	SVG_MARGIN      = 40.0
+	SVG_LABEL_WIDTH = 170.0
+
+	COLOR_PLANET     = "#3b82c4"
+	COLOR_REPLICATOR = "#e08a1e"
+	COLOR_OTHER      = "#999999"
+
+	ANSI_PLANET     = "\x1b[34m"
+	ANSI_REPLICATOR = "\x1b[33m"
+	ANSI_RESET      = "\x1b[0m"
+)
+
+type MapOptions struct {
+	// ASCII图的宽度(字符数)和每一行对应的OffsetY
+	Width int
+	Step  float64
+	// 终端里用颜色区分星球和复制器
+	Color bool
+}
+
+func DefaultMapOptions() MapOptions {
+	return MapOptions{Width: 21, Step: 1.5}
+}
+
+func (this *Colony) Label() string {
+	return fmt.Sprintf("#%v %v L%v", this.ColonyOrder, this.Identifier, this.Level)
+}
+
+func colonyColor(colony *Colony) string {
+	switch colony.ColonyType {
+	case COLONY_PLANET:
+		return COLOR_PLANET
+	case COLONY_REPLICATOR:
+		return COLOR_REPLICATOR
+	}
+	return COLOR_OTHER
+}
+
+func colonyMarker(colony *Colony) string {
+	if colony.IsPlanet() {
+		return "O"
+	}
+	return "*"
+}
+
+// 所有游戏数据里OffsetY的范围
+func offsetRange(snapshots []*GameData) (min, max float64) {
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, data := range snapshots {
+		for _, colony := range data.Colonies {
+			min = math.Min(min, colony.OffsetY)
+			max = math.Max(max, colony.OffsetY)
+		}
+	}
+	if min > max {
+		return 0, 0
+	}
+	return min, max
+}
+
+func WriteSVG(w io.Writer, titles []string, snapshots []*GameData) error {
+	if len(titles) != len(snapshots) {
+		return fmt.Errorf("标题和游戏数据的数量不一致")
+	}
+
+	minY, maxY := offsetRange(snapshots)
+	panelWidth := SVG_MARGIN*2 + SVG_SCALE_X*2 + SVG_LABEL_WIDTH
+	width := panelWidth * float64(len(snapshots))
+	height := SVG_MARGIN*2 + (maxY-minY)*SVG_SCALE_Y
+
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" font-family=\"sans-serif\" font-size=\"11\">\n", width, height)
+	fmt.Fprintf(w, "<rect width=\"100%%\" height=\"100%%\" fill=\"#0b1020\"/>\n")
+	for i, data := range snapshots {
+		left := panelWidth * float64(i)
+		point := func(colony *Colony) (float64, float64) {
+			return left + SVG_MARGIN + (colony.OffsetX+1)*SVG_SCALE_X, SVG_MARGIN + (maxY-colony.OffsetY)*SVG_SCALE_Y
+		}
+
+		fmt.Fprintf(w, "<g>\n<text x=\"%.1f\" y=\"%.1f\" fill=\"#ffffff\" font-size=\"14\">%v</text>\n", left+SVG_MARGIN, SVG_MARGIN/2, html.EscapeString(titles[i]))
+
+		// 按顺序连起来, 就是星球排列的路线
+		points := []string{}
+		for j := range data.Colonies {
+			x, y := point(&data.Colonies[j])
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		fmt.Fprintf(w, "<polyline points=\"%v\" fill=\"none\" stroke=\"#445066\" stroke-width=\"1\"/>\n", strings.Join(points, " "))
+
+		for j := range data.Colonies {
+			colony := &data.Colonies[j]
+			x, y := point(colony)
+			fmt.Fprintf(w, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"6\" fill=\"%v\"><title>%v</title></circle>\n", x, y, colonyColor(colony), html.EscapeString(colony.Label()))
+			fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%.1f\" fill=\"#dddddd\">%v</text>\n", x+10, y+4, html.EscapeString(colony.Label()))
+		}
+		fmt.Fprintln(w, "</g>")
+	}
+	_, err := fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// 一行对应options.Step的OffsetY, 同一行的星球标签用逗号分开, 几份数据并排
+func WriteASCII(w io.Writer, titles []string, snapshots []*GameData, options MapOptions) error {
+	if len(titles) != len(snapshots) {
+		return fmt.Errorf("标题和游戏数据的数量不一致")
+	}
+	if options.Width < 3 || options.Step <= 0 {
+		return fmt.Errorf("宽度[%v]或者步长[%v]不对", options.Width, options.Step)
+	}
+
+	minY, maxY := offsetRange(snapshots)
+	rows := int((maxY-minY)/options.Step) + 1
+
+	type cell struct {
+		marker string
+		colony *Colony
+	}
+	grids := make([][][]*cell, len(snapshots))
+	labels := make([][]string, len(snapshots))
+	labelWidth := make([]int, len(snapshots))
+	for i, data := range snapshots {
+		grids[i] = make([][]*cell, rows)
+		labels[i] = make([]string, rows)
+		for row := range grids[i] {
+			grids[i][row] = make([]*cell, options.Width)
+		}
+		for j := range data.Colonies {
+			colony := &data.Colonies[j]
+			row := int((maxY - colony.OffsetY) / options.Step)
+			column := int(math.Floor((colony.OffsetX+1)/2*float64(options.Width-1) + 0.5))
+			if column < 0 {
+				column = 0
+			} else if column >= options.Width {
+				column = options.Width - 1
+			}
+			grids[i][row][column] = &cell{marker: colonyMarker(colony), colony: colony}
+			if labels[i][row] != "" {
+				labels[i][row] += ", "
+			}
+			labels[i][row] += colony.Label()
+		}
+		labelWidth[i] = len(titles[i])
+		for _, label := range labels[i] {
+			if len(label) > labelWidth[i] {
+				labelWidth[i] = len(label)
+			}
+		}
+	}
+
+	var line bytes.Buffer
+	for i, title := range titles {
+		line.WriteString(fmt.Sprintf("%-*v  %-*v", options.Width+2, "", labelWidth[i], title))
+		if i < len(titles)-1 {
+			line.WriteString("    ")
+		}
+	}
+	if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+		return err
+	}
+
+	for row := 0; row < rows; row++ {
+		line.Reset()
+		for i := range snapshots {
+			line.WriteString("|")
+			for _, c := range grids[i][row] {
+				switch {
+				case c == nil:
+					line.WriteString(" ")
+				case options.Color && c.colony.IsPlanet():
+					line.WriteString(ANSI_PLANET + c.marker + ANSI_RESET)
+				case options.Color && c.colony.ColonyType == COLONY_REPLICATOR:
+					line.WriteString(ANSI_REPLICATOR + c.marker + ANSI_RESET)
+				default:
+					line.WriteString(c.marker)
+				}
+			}
+			line.WriteString("|  ")
+			line.WriteString(fmt.Sprintf("%-*v", labelWidth[i], labels[i][row]))
+			if i < len(snapshots)-1 {
+				line.WriteString("    ")
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "O: %v  *: %v\n", COLONY_PLANET, COLONY_REPLICATOR)
+	return err
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// game.json的内容, 时间都解析成time.Time, 序列化的时候还原成原来的格式
+//
+// 每个对象都会记住原来的JSON, 不认识的字段和没有修改过的字段原样输出, 字段的顺序也不变;
+// 新建的对象按game.json的字段顺序输出
+type GameResponse struct {
+	Success bool
+	Data    GameData
+
+	raw *original
+}
+
+type GameData struct {
+	Coins  int64
+	Cubes  int
+	Energy int
+	// 每个星球最后一次喂食和收获的时间, 和Colonies的顺序一致, 没有的话是零值
+	FedTimes       []time.Time
+	Food           int
+	HarvestedTimes []time.Time
+	Level          int
+	Ltvalue        float64
+	Population     int
+	Spaceship      string
+	Colonies       []Colony
+	Satellites     []Satellite
+	Missions       []Mission
+	Activities     []Activity
+	Spaceships     []Spaceship
+	Achievements   []Achievement
+	Messages       []int
+
+	raw *original
+}
+
+type Achievement struct {
+	Completed  bool
+	Identifier string
+	Progress   float64
+	UpdatedAt  time.Time
+
+	raw *original
+}
+
+type Spaceship struct {
+	Identifier string
+	UpdatedAt  time.Time
+
+	raw *original
+}
+
+type Activity struct {
+	Date      time.Time
+	Running   int
+	UpdatedAt time.Time
+	Walking   int
+
+	raw *original
+}
+
+type Mission struct {
+	Aborted    bool
+	Completed  bool
+	Identifier string
+	ResourceA  int
+	ResourceB  int
+	ResourceC  int
+	UpdatedAt  time.Time
+
+	raw *original
+}
+
+type Colony struct {
+	// 复制器没有分类, 是空字符串(原来是null)
+	Category     string
+	ColonyOrder  int
+	ColonyType   string
+	Completed    bool
+	DiscoveredAt time.Time
+	GalaxyId     int
+	Identifier   string
+	Level        int
+	OffsetX      float64
+	OffsetY      float64
+	ProcessTime  float64
+	ProcessedAt  time.Time
+	UpdatedAt    time.Time
+	ZPosition    float64
+	ZRotation    float64
+	Satellites   []MiniSatellite
+
+	raw *original
+}
+
+type MiniSatellite struct {
+	Identifier string
+
+	raw *original
+}
+
+type Satellite struct {
+	// 没有放到星球上的卫星是0(原来是null)
+	ColonyId   int
+	Identifier string
+	UpdatedAt  time.Time
+
+	raw *original
+}
+
+const (
+	COLONY_PLANET     = "planet"
+	COLONY_REPLICATOR = "replicator"
+)
+
+func (this *Colony) IsPlanet() bool {
+	return this.ColonyType == COLONY_PLANET
+}
+
+// 第index个星球最后一次喂食的时间
+func (this *GameData) FedAt(index int) time.Time {
+	if index < 0 || index >= len(this.FedTimes) {
+		return time.Time{}
+	}
+	return this.FedTimes[index]
+}
+
+// 第index个星球最后一次收获的时间
+func (this *GameData) HarvestedAt(index int) time.Time {
+	if index < 0 || index >= len(this.HarvestedTimes) {
+		return time.Time{}
+	}
+	return this.HarvestedTimes[index]
+}
+
+func Load(path string) (*GameResponse, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析[%v]失败: %v", path, err)
+	}
+	return result, nil
+}
+
+func Parse(data []byte) (*GameResponse, error) {
+	result := &GameResponse{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
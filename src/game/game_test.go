@@ -0,0 +1,20 @@
+package game
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// 仓库里的game.json和out.json解析之后再序列化, 必须和原文件一个字节都不差
+func TestRoundtripGolden(t *testing.T) {
+	for _, name := range []string{"game.json", "out.json"} {
+		data, err := ioutil.ReadFile(filepath.Join("..", "..", name))
+		if err != nil {
+			t.Fatalf("读取[%v]失败: %v", name, err)
+		}
+		if err := CheckRoundtrip(data); err != nil {
+			t.Errorf("[%v]没有通过: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON对象的字段, 保留原来的顺序, 值都是压缩过的原始JSON
+type rawObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func newRawObject() *rawObject {
+	return &rawObject{values: make(map[string]json.RawMessage)}
+}
+
+func (this *rawObject) set(key string, value json.RawMessage) {
+	if _, ok := this.values[key]; !ok {
+		this.keys = append(this.keys, key)
+	}
+	this.values[key] = value
+}
+
+func (this *rawObject) has(key string) bool {
+	_, ok := this.values[key]
+	return ok
+}
+
+func (this *rawObject) marshal() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range this.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, _ := json.Marshal(key)
+		buf.Write(b)
+		buf.WriteByte(':')
+		buf.Write(this.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// 不是对象(比如null)的话返回nil
+func parseObject(b []byte) (*rawObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, nil
+	}
+
+	object := newRawObject()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("字段名[%v]不是字符串", token)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, value); err != nil {
+			return nil, err
+		}
+		object.set(key, compacted.Bytes())
+	}
+
+	return object, nil
+}
+
+// 反序列化时的原始对象, 以及已知字段刚解析完时的序列化结果
+//
+// 序列化的时候已知字段和解析时一样的话直接使用原始的值, 不认识的字段原样保留,
+// 这样只有修改过的字段会变化, 数字的写法、null和字段的顺序都和原来一样
+type original struct {
+	fields  *rawObject
+	decoded *rawObject
+}
+
+// null会把wire里的指针设置成nil, 所以要用一个新的wire来序列化解析之后的值
+func unmarshalObject(b []byte, wire interface{}, fresh interface{}) (*original, error) {
+	fields, err := parseObject(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, wire); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, nil
+	}
+
+	decoded, err := encodeObject(fresh)
+	if err != nil {
+		return nil, err
+	}
+	return &original{fields: fields, decoded: decoded}, nil
+}
+
+func marshalObject(wire interface{}, orig *original) ([]byte, error) {
+	current, err := encodeObject(wire)
+	if err != nil {
+		return nil, err
+	}
+	if orig == nil {
+		return current.marshal(), nil
+	}
+
+	object := newRawObject()
+	for _, key := range orig.fields.keys {
+		value := orig.fields.values[key]
+		if current.has(key) && !bytes.Equal(current.values[key], orig.decoded.values[key]) {
+			value = current.values[key]
+		}
+		object.set(key, value)
+	}
+	// 原来没有的字段只有修改过才输出
+	for _, key := range current.keys {
+		if !orig.fields.has(key) && !bytes.Equal(current.values[key], orig.decoded.values[key]) {
+			object.set(key, current.values[key])
+		}
+	}
+
+	return object.marshal(), nil
+}
+
+func encodeObject(wire interface{}) (*rawObject, error) {
+	b, err := json.Marshal(wire)
+	if err != nil {
+		return nil, err
+	}
+	return parseObject(b)
+}
+
+// 解析之后再序列化, 结果应该和压缩之后的原文件完全一样, 不一样的话返回第一个不同的位置
+func CheckRoundtrip(data []byte) error {
+	var expected bytes.Buffer
+	if err := json.Compact(&expected, data); err != nil {
+		return err
+	}
+
+	var result GameResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	actual, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(expected.Bytes(), actual) {
+		return nil
+	}
+
+	a, b := expected.Bytes(), actual
+	offset := 0
+	for offset < len(a) && offset < len(b) && a[offset] == b[offset] {
+		offset++
+	}
+	return fmt.Errorf("第%v个字节开始不一样:\n原来: %s\n现在: %s", offset, excerpt(a, offset), excerpt(b, offset))
+}
+
+func excerpt(b []byte, offset int) []byte {
+	start, end := offset-60, offset+60
+	if start < 0 {
+		start = 0
+	}
+	if end > len(b) {
+		end = len(b)
+	}
+	return b[start:end]
+}
@@ -0,0 +1,51 @@
+package game
+
+// 调整星球的顺序: 前两个位置不动, 之后每4个位置里第1个放复制器, 其余3个按顺序放星球, 星球用完之后都放复制器;
+// 位置、偏移和时间用原来这个位置上的, 其他字段从原来的星球(或者这个位置上的星球)复制过来, 原始JSON也会保留
+func (this *GameData) Reorder() {
+	positionMap := map[int]Colony{}
+	planets := []Colony{}
+	for index, c := range this.Colonies {
+		positionMap[c.ColonyOrder] = c
+		if index >= 2 && c.IsPlanet() {
+			planets = append(planets, c)
+		}
+	}
+
+	colonies := make([]Colony, len(this.Colonies))
+	for index := range colonies {
+		slot := positionMap[index+1]
+		if index < 2 {
+			colonies[index] = slot
+			continue
+		}
+
+		var colony Colony
+		if (index-1)%4 != 0 && len(planets) > 0 && planets[0].Category != "" {
+			colony = planets[0]
+			planets = planets[1:]
+			colony.ProcessTime = slot.ProcessTime
+		} else {
+			colony = slot
+			colony.Category = ""
+			colony.ColonyType = COLONY_REPLICATOR
+			colony.Identifier = "blender"
+			colony.Level = 8
+			colony.ProcessTime = 0
+			colony.Satellites = []MiniSatellite{}
+		}
+		colony.ColonyOrder = index + 1
+		colony.Completed = true
+		colony.GalaxyId = 1
+		colony.DiscoveredAt = slot.DiscoveredAt
+		colony.OffsetX = slot.OffsetX
+		colony.OffsetY = slot.OffsetY
+		colony.ProcessedAt = slot.ProcessedAt
+		colony.UpdatedAt = slot.UpdatedAt
+		colony.ZPosition = slot.ZPosition
+		colony.ZRotation = slot.ZRotation
+		colonies[index] = colony
+	}
+
+	this.Colonies = colonies
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// Reorder只改顺序、位置、时间和星球本身的字段, 其他字段要和原来的JSON一个字节都不差(和CheckRoundtrip一样去掉空白)
+func TestReorderGolden(t *testing.T) {
+	indented, err := ioutil.ReadFile(filepath.Join("..", "..", "game.json"))
+	if err != nil {
+		t.Fatalf("读取game.json失败: %v", err)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, indented); err != nil {
+		t.Fatalf("game.json格式有问题: %v", err)
+	}
+	data := compacted.Bytes()
+	result, err := Parse(data)
+	if err != nil {
+		t.Fatalf("解析game.json失败: %v", err)
+	}
+	result.Data.Reorder()
+	output, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+
+	before, beforeColonies := splitColonies(t, data)
+	after, afterColonies := splitColonies(t, output)
+	for key, value := range before {
+		if !bytes.Equal(value, after[key]) {
+			t.Errorf("[%v]不应该变, 原来是%s, 结果是%s", key, value, after[key])
+		}
+	}
+	if len(afterColonies) != len(beforeColonies) {
+		t.Fatalf("应该有%v个星球, 结果是%v个", len(beforeColonies), len(afterColonies))
+	}
+
+	slots := make(map[string]map[string]json.RawMessage)
+	planets := make(map[string]map[string]json.RawMessage)
+	for _, colony := range beforeColonies {
+		slots[string(colony["colony_order"])] = colony
+		if string(colony["colony_type"]) == `"planet"` {
+			planets[string(colony["identifier"])] = colony
+		}
+	}
+
+	moved := map[string]bool{"colony_order": true, "completed": true, "galaxy_id": true, "process_time": true,
+		"discovered_at": true, "processed_at": true, "updated_at": true,
+		"offset_x": true, "offset_y": true, "z_position": true, "z_rotation": true}
+	replicator := map[string]bool{"category": true, "colony_type": true, "identifier": true, "level": true, "satellites": true}
+	for i, colony := range afterColonies {
+		slot := slots[string(colony["colony_order"])]
+		source, skip := slot, moved
+		if string(colony["colony_type"]) == `"planet"` {
+			source = planets[string(colony["identifier"])]
+		} else {
+			skip = copySet(moved)
+			for key := range replicator {
+				skip[key] = true
+			}
+			// 改成复制器的时候, 原来是null的分类不能变成空字符串
+			if string(slot["category"]) == "null" && string(colony["category"]) != "null" {
+				t.Errorf("第%v个星球的[category]应该是null, 结果是%s", i, colony["category"])
+			}
+		}
+		if source == nil {
+			t.Fatalf("第%v个星球%s找不到原来的数据", i, colony["identifier"])
+		}
+
+		if len(colony) != len(source) {
+			t.Errorf("第%v个星球的字段数量应该是%v, 结果是%v", i, len(source), len(colony))
+		}
+		for key, value := range source {
+			if _, ok := colony[key]; !ok {
+				t.Errorf("第%v个星球少了[%v]", i, key)
+				continue
+			}
+			if !skip[key] && !bytes.Equal(value, colony[key]) {
+				t.Errorf("第%v个星球的[%v]应该是%s, 结果是%s", i, key, value, colony[key])
+			}
+		}
+	}
+}
+
+func splitColonies(t *testing.T, b []byte) (map[string]json.RawMessage, []map[string]json.RawMessage) {
+	t.Helper()
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &response); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	var colonies []map[string]json.RawMessage
+	if err := json.Unmarshal(response.Data["colonies"], &colonies); err != nil {
+		t.Fatalf("解析colonies失败: %v", err)
+	}
+	delete(response.Data, "colonies")
+	return response.Data, colonies
+}
+
+func copySet(set map[string]bool) map[string]bool {
+	copied := make(map[string]bool)
+	for key := range set {
+		copied[key] = true
+	}
+	return copied
+}
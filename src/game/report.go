@@ -0,0 +1,249 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 一个账号拥有的东西, 只读, 不修改游戏数据
+type Report struct {
+	Resources    Resources          `json:"resources"`
+	ColonyGroups []ColonyGroup      `json:"colony_groups"`
+	Satellites   SatelliteSummary   `json:"satellites"`
+	Missions     MissionSummary     `json:"missions"`
+	Achievements AchievementSummary `json:"achievements"`
+}
+
+type Resources struct {
+	Coins      int64 `json:"coins"`
+	Cubes      int   `json:"cubes"`
+	Energy     int   `json:"energy"`
+	Food       int   `json:"food"`
+	Population int   `json:"population"`
+	Level      int   `json:"level"`
+}
+
+// 按ColonyType和Category分组, Levels是每个等级的数量
+type ColonyGroup struct {
+	Type         string      `json:"type"`
+	Category     string      `json:"category"`
+	Count        int         `json:"count"`
+	Levels       map[int]int `json:"levels"`
+	AverageLevel float64     `json:"average_level"`
+}
+
+type SatelliteSummary struct {
+	Total    int                `json:"total"`
+	Colonies []ColonySatellites `json:"colonies"`
+	// 没有放到星球上的卫星
+	Unplaced []string `json:"unplaced"`
+}
+
+type ColonySatellites struct {
+	ColonyOrder int      `json:"colony_order"`
+	Identifier  string   `json:"identifier"`
+	Satellites  []string `json:"satellites"`
+}
+
+type MissionSummary struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Aborted   int `json:"aborted"`
+	// 既没有完成也没有放弃的任务
+	Open      int           `json:"open"`
+	ResourceA int           `json:"resource_a"`
+	ResourceB int           `json:"resource_b"`
+	ResourceC int           `json:"resource_c"`
+	OpenList  []MissionItem `json:"open_list"`
+}
+
+type MissionItem struct {
+	Identifier string    `json:"identifier"`
+	ResourceA  int       `json:"resource_a"`
+	ResourceB  int       `json:"resource_b"`
+	ResourceC  int       `json:"resource_c"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type AchievementSummary struct {
+	Total     int               `json:"total"`
+	Completed int               `json:"completed"`
+	Percent   float64           `json:"percent"`
+	OpenList  []AchievementItem `json:"open_list"`
+}
+
+type AchievementItem struct {
+	Identifier string  `json:"identifier"`
+	Progress   float64 `json:"progress"`
+}
+
+func NewReport(data *GameData) Report {
+	report := Report{
+		Resources: Resources{
+			Coins:      data.Coins,
+			Cubes:      data.Cubes,
+			Energy:     data.Energy,
+			Food:       data.Food,
+			Population: data.Population,
+			Level:      data.Level,
+		},
+		ColonyGroups: []ColonyGroup{},
+		Satellites:   SatelliteSummary{Colonies: []ColonySatellites{}, Unplaced: []string{}},
+		Missions:     MissionSummary{OpenList: []MissionItem{}},
+		Achievements: AchievementSummary{OpenList: []AchievementItem{}},
+	}
+
+	groups := make(map[string]*ColonyGroup)
+	for _, colony := range data.Colonies {
+		key := colony.ColonyType + "\n" + colony.Category
+		group, ok := groups[key]
+		if !ok {
+			group = &ColonyGroup{Type: colony.ColonyType, Category: colony.Category, Levels: make(map[int]int)}
+			groups[key] = group
+		}
+		group.Count += 1
+		group.Levels[colony.Level] += 1
+		group.AverageLevel += float64(colony.Level)
+
+		if len(colony.Satellites) > 0 {
+			satellites := []string{}
+			for _, satellite := range colony.Satellites {
+				satellites = append(satellites, satellite.Identifier)
+			}
+			report.Satellites.Colonies = append(report.Satellites.Colonies,
+				ColonySatellites{ColonyOrder: colony.ColonyOrder, Identifier: colony.Identifier, Satellites: satellites})
+		}
+	}
+	for _, group := range groups {
+		group.AverageLevel /= float64(group.Count)
+		report.ColonyGroups = append(report.ColonyGroups, *group)
+	}
+	sort.Slice(report.ColonyGroups, func(i, j int) bool {
+		a, b := report.ColonyGroups[i], report.ColonyGroups[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Category < b.Category
+	})
+
+	report.Satellites.Total = len(data.Satellites)
+	for _, satellite := range data.Satellites {
+		if satellite.ColonyId == 0 {
+			report.Satellites.Unplaced = append(report.Satellites.Unplaced, satellite.Identifier)
+		}
+	}
+
+	for _, mission := range data.Missions {
+		report.Missions.Total += 1
+		report.Missions.ResourceA += mission.ResourceA
+		report.Missions.ResourceB += mission.ResourceB
+		report.Missions.ResourceC += mission.ResourceC
+		switch {
+		case mission.Completed:
+			report.Missions.Completed += 1
+		case mission.Aborted:
+			report.Missions.Aborted += 1
+		default:
+			report.Missions.Open += 1
+			report.Missions.OpenList = append(report.Missions.OpenList, MissionItem{
+				Identifier: mission.Identifier,
+				ResourceA:  mission.ResourceA,
+				ResourceB:  mission.ResourceB,
+				ResourceC:  mission.ResourceC,
+				UpdatedAt:  mission.UpdatedAt,
+			})
+		}
+	}
+
+	for _, achievement := range data.Achievements {
+		report.Achievements.Total += 1
+		if achievement.Completed {
+			report.Achievements.Completed += 1
+		} else {
+			report.Achievements.OpenList = append(report.Achievements.OpenList,
+				AchievementItem{Identifier: achievement.Identifier, Progress: achievement.Progress})
+		}
+	}
+	if report.Achievements.Total > 0 {
+		report.Achievements.Percent = float64(report.Achievements.Completed) * 100 / float64(report.Achievements.Total)
+	}
+
+	return report
+}
+
+func WriteReport(w io.Writer, format string, report Report) error {
+	switch format {
+	case "table":
+		return WriteReportTable(w, report)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(report)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
+
+func WriteReportTable(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	resources := report.Resources
+	fmt.Fprintln(tw, "金币\t能量块\t能量\t食物\t人口\t等级")
+	fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", resources.Coins, resources.Cubes, resources.Energy, resources.Food, resources.Population, resources.Level)
+
+	fmt.Fprintln(tw, "\n类型\t分类\t数量\t平均等级\t等级分布")
+	for _, group := range report.ColonyGroups {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%.1f\t%v\n", group.Type, orDash(group.Category), group.Count, group.AverageLevel, formatLevels(group.Levels))
+	}
+
+	fmt.Fprintf(tw, "\n卫星(共%v个, 未放置%v个)\n", report.Satellites.Total, len(report.Satellites.Unplaced))
+	fmt.Fprintln(tw, "顺序\t星球\t卫星")
+	for _, colony := range report.Satellites.Colonies {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", colony.ColonyOrder, colony.Identifier, strings.Join(colony.Satellites, ", "))
+	}
+	if len(report.Satellites.Unplaced) > 0 {
+		fmt.Fprintf(tw, "-\t未放置\t%v\n", strings.Join(report.Satellites.Unplaced, ", "))
+	}
+
+	missions := report.Missions
+	fmt.Fprintln(tw, "\n任务\t完成\t放弃\t进行中\t资源A\t资源B\t资源C")
+	fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", missions.Total, missions.Completed, missions.Aborted, missions.Open, missions.ResourceA, missions.ResourceB, missions.ResourceC)
+	for _, mission := range missions.OpenList {
+		fmt.Fprintf(tw, "  %v\t\t\t进行中\t%v\t%v\t%v\n", mission.Identifier, mission.ResourceA, mission.ResourceB, mission.ResourceC)
+	}
+
+	achievements := report.Achievements
+	fmt.Fprintln(tw, "\n成就\t完成\t完成度")
+	fmt.Fprintf(tw, "%v\t%v\t%.1f%%\n", achievements.Total, achievements.Completed, achievements.Percent)
+	for _, achievement := range achievements.OpenList {
+		fmt.Fprintf(tw, "  %v\t\t%.0f%%\n", achievement.Identifier, achievement.Progress*100)
+	}
+
+	return tw.Flush()
+}
+
+// 比如 L1×3 L5×2
+func formatLevels(levels map[int]int) string {
+	keys := []int{}
+	for level := range levels {
+		keys = append(keys, level)
+	}
+	sort.Ints(keys)
+
+	parts := []string{}
+	for _, level := range keys {
+		parts = append(parts, fmt.Sprintf("L%v×%v", level, levels[level]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 服务器返回的时间都是UTC
+const TimeLayout = "2006-01-02 15:04:05"
+
+// *_at字段, null或者空字符串是零值, 零值序列化成null
+type jsonTime time.Time
+
+func (this jsonTime) MarshalJSON() ([]byte, error) {
+	t := time.Time(this)
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(TimeLayout))
+}
+
+func (this *jsonTime) UnmarshalJSON(b []byte) error {
+	var value *string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		*this = jsonTime{}
+		return nil
+	}
+
+	t, err := time.ParseInLocation(TimeLayout, *value, time.UTC)
+	if err != nil {
+		return fmt.Errorf("时间[%v]格式有问题: %v", *value, err)
+	}
+	*this = jsonTime(t)
+	return nil
+}
+
+// fed_times和harvested_times, 逗号分开的Unix秒数, 0是零值
+type unixTimes []time.Time
+
+func (this unixTimes) MarshalJSON() ([]byte, error) {
+	parts := make([]string, len(this))
+	for i, t := range this {
+		if t.IsZero() {
+			parts[i] = "0"
+		} else {
+			parts[i] = strconv.FormatInt(t.Unix(), 10)
+		}
+	}
+	return json.Marshal(strings.Join(parts, ","))
+}
+
+func (this *unixTimes) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+
+	times := unixTimes{}
+	if value != "" {
+		for _, part := range strings.Split(value, ",") {
+			seconds, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return fmt.Errorf("时间[%v]不是Unix秒数: %v", part, err)
+			}
+			if seconds == 0 {
+				times = append(times, time.Time{})
+			} else {
+				times = append(times, time.Unix(seconds, 0).UTC())
+			}
+		}
+	}
+	*this = times
+	return nil
+}
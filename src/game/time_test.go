@@ -0,0 +1,162 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJsonTimeUnmarshal(t *testing.T) {
+	for _, item := range []struct {
+		name  string
+		input string
+		want  time.Time
+		err   bool
+	}{
+		{"null", `null`, time.Time{}, false},
+		{"空字符串", `""`, time.Time{}, false},
+		{"UTC", `"2016-03-04 05:06:07"`, time.Date(2016, 3, 4, 5, 6, 7, 0, time.UTC), false},
+		{"小数秒", `"2016-03-04 05:06:07.25"`, time.Date(2016, 3, 4, 5, 6, 7, 250000000, time.UTC), false},
+		{"带时区", `"2016-03-04 05:06:07 +0800"`, time.Time{}, true},
+		{"ISO格式", `"2016-03-04T05:06:07Z"`, time.Time{}, true},
+		{"数字", `0`, time.Time{}, true},
+	} {
+		var value jsonTime
+		err := json.Unmarshal([]byte(item.input), &value)
+		if item.err {
+			if err == nil {
+				t.Errorf("[%v]应该解析失败, 结果是%v", item.name, time.Time(value))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%v]解析失败: %v", item.name, err)
+			continue
+		}
+		if got := time.Time(value); !got.Equal(item.want) || got.IsZero() != item.want.IsZero() {
+			t.Errorf("[%v]应该是%v, 结果是%v", item.name, item.want, got)
+		}
+	}
+}
+
+func TestJsonTimeMarshal(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*60*60)
+	for _, item := range []struct {
+		name  string
+		input time.Time
+		want  string
+	}{
+		{"零值", time.Time{}, `null`},
+		{"UTC", time.Date(2016, 3, 4, 5, 6, 7, 0, time.UTC), `"2016-03-04 05:06:07"`},
+		// 统一转成UTC, 不足一秒的部分丢掉
+		{"带时区", time.Date(2016, 3, 4, 5, 6, 7, 0, shanghai), `"2016-03-03 21:06:07"`},
+		{"小数秒", time.Date(2016, 3, 4, 5, 6, 7, 250000000, time.UTC), `"2016-03-04 05:06:07"`},
+	} {
+		b, err := json.Marshal(jsonTime(item.input))
+		if err != nil {
+			t.Errorf("[%v]序列化失败: %v", item.name, err)
+			continue
+		}
+		if string(b) != item.want {
+			t.Errorf("[%v]应该是%v, 结果是%s", item.name, item.want, b)
+		}
+	}
+}
+
+func TestUnixTimesUnmarshal(t *testing.T) {
+	for _, item := range []struct {
+		name  string
+		input string
+		want  []time.Time
+		err   bool
+	}{
+		{"空字符串", `""`, []time.Time{}, false},
+		{"零值", `"0,1457067967,0"`, []time.Time{{}, time.Unix(1457067967, 0), {}}, false},
+		{"小数秒", `"1457067967.5"`, nil, true},
+		{"不是数字", `"1457067967,abc"`, nil, true},
+		{"null", `null`, []time.Time{}, false},
+		{"数组", `[1457067967]`, nil, true},
+	} {
+		var value unixTimes
+		err := json.Unmarshal([]byte(item.input), &value)
+		if item.err {
+			if err == nil {
+				t.Errorf("[%v]应该解析失败, 结果是%v", item.name, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%v]解析失败: %v", item.name, err)
+			continue
+		}
+		if len(value) != len(item.want) {
+			t.Errorf("[%v]应该有%v个时间, 结果是%v", item.name, len(item.want), value)
+			continue
+		}
+		for i := range value {
+			if !value[i].Equal(item.want[i]) || value[i].IsZero() != item.want[i].IsZero() {
+				t.Errorf("[%v]第%v个应该是%v, 结果是%v", item.name, i, item.want[i], value[i])
+			}
+		}
+	}
+}
+
+func TestUnixTimesMarshal(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*60*60)
+	for _, item := range []struct {
+		name  string
+		input unixTimes
+		want  string
+	}{
+		{"空", unixTimes{}, `""`},
+		{"零值", unixTimes{{}, time.Unix(1457067967, 0)}, `"0,1457067967"`},
+		// Unix秒数和时区无关, 不足一秒的部分丢掉
+		{"带时区", unixTimes{time.Unix(1457067967, 0).In(shanghai)}, `"1457067967"`},
+		{"小数秒", unixTimes{time.Unix(1457067967, 750000000)}, `"1457067967"`},
+	} {
+		b, err := json.Marshal(item.input)
+		if err != nil {
+			t.Errorf("[%v]序列化失败: %v", item.name, err)
+			continue
+		}
+		if string(b) != item.want {
+			t.Errorf("[%v]应该是%v, 结果是%s", item.name, item.want, b)
+		}
+	}
+}
+
+// activities里的日期字段叫date, 对应Activity.Date
+func TestActivityDate(t *testing.T) {
+	input := `{"date":"2016-03-04 00:00:00","running":12,"updated_at":null,"walking":3456}`
+	var activity Activity
+	if err := json.Unmarshal([]byte(input), &activity); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if want := time.Date(2016, 3, 4, 0, 0, 0, 0, time.UTC); !activity.Date.Equal(want) {
+		t.Errorf("Date应该是%v, 结果是%v", want, activity.Date)
+	}
+	if !activity.UpdatedAt.IsZero() || activity.Running != 12 || activity.Walking != 3456 {
+		t.Errorf("解析结果有问题: %+v", activity)
+	}
+
+	b, err := json.Marshal(activity)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	if string(b) != input {
+		t.Errorf("应该是%v, 结果是%s", input, b)
+	}
+
+	// 新建的Activity没有原始的JSON, 也要用date
+	b, err = json.Marshal(Activity{Date: activity.Date})
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if fields["date"] != "2016-03-04 00:00:00" {
+		t.Errorf("date应该是2016-03-04 00:00:00, 结果是%s", b)
+	}
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 每个星球最后一次喂食和收获的时间, 从fed_times和harvested_times按下标对应到星球
+type ColonyTiming struct {
+	ColonyOrder int
+	Identifier  string
+	ColonyType  string
+	FedAt       time.Time
+	HarvestedAt time.Time
+	// 到Timing.At为止过了多久, 没有喂过或者收获过的话是0
+	SinceFed       time.Duration
+	SinceHarvested time.Duration
+}
+
+func (this *ColonyTiming) NeverFed() bool {
+	return this.FedAt.IsZero()
+}
+
+func (this *ColonyTiming) NeverHarvested() bool {
+	return this.HarvestedAt.IsZero()
+}
+
+// 每个小时里最后一次喂食和收获的星球数量
+type HourActivity struct {
+	Hour      int
+	Fed       int
+	Harvested int
+}
+
+type Timing struct {
+	At       time.Time
+	Location *time.Location
+	Colonies []ColonyTiming
+	Hours    []HourActivity
+}
+
+// 游戏数据里最晚的喂食或者收获时间, 一般就是下载游戏数据的时候
+func (this *GameData) LatestTime() time.Time {
+	latest := time.Time{}
+	for _, times := range [][]time.Time{this.FedTimes, this.HarvestedTimes} {
+		for _, t := range times {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
+
+// at是计算过了多久的时间, 零值的话用LatestTime; 按location里的小时统计
+func NewTiming(data *GameData, at time.Time, location *time.Location) Timing {
+	if at.IsZero() {
+		at = data.LatestTime()
+	}
+	timing := Timing{At: at, Location: location, Colonies: []ColonyTiming{}, Hours: make([]HourActivity, 24)}
+	for hour := range timing.Hours {
+		timing.Hours[hour].Hour = hour
+	}
+
+	for i, colony := range data.Colonies {
+		item := ColonyTiming{
+			ColonyOrder: colony.ColonyOrder,
+			Identifier:  colony.Identifier,
+			ColonyType:  colony.ColonyType,
+			FedAt:       data.FedAt(i),
+			HarvestedAt: data.HarvestedAt(i),
+		}
+		if !item.NeverFed() {
+			item.SinceFed = at.Sub(item.FedAt)
+			timing.Hours[item.FedAt.In(location).Hour()].Fed += 1
+		}
+		if !item.NeverHarvested() {
+			item.SinceHarvested = at.Sub(item.HarvestedAt)
+			timing.Hours[item.HarvestedAt.In(location).Hour()].Harvested += 1
+		}
+		timing.Colonies = append(timing.Colonies, item)
+	}
+	return timing
+}
+
+// 从来没有喂过的星球
+func (this *Timing) NeverFed() []ColonyTiming {
+	colonies := []ColonyTiming{}
+	for _, colony := range this.Colonies {
+		if colony.NeverFed() {
+			colonies = append(colonies, colony)
+		}
+	}
+	return colonies
+}
+
+const (
+	TIMING_COLONIES = "colonies"
+	TIMING_HOURS    = "hours"
+	TIMING_ALL      = "all"
+)
+
+// view是colonies, hours或者all; csv一次只能输出一种
+func WriteTiming(w io.Writer, format string, view string, timing Timing) error {
+	switch format {
+	case "table":
+		return WriteTimingTable(w, view, timing)
+	case "csv":
+		return WriteTimingCSV(w, view, timing)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
+
+func WriteTimingTable(w io.Writer, view string, timing Timing) error {
+	if view != TIMING_COLONIES && view != TIMING_HOURS && view != TIMING_ALL {
+		return fmt.Errorf("不支持的视图[%v]", view)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "截止时间: %v\n", timing.At.In(timing.Location).Format("2006-01-02 15:04:05"))
+	if view == TIMING_COLONIES || view == TIMING_ALL {
+		fmt.Fprintln(tw, "\n顺序\t星球\t类型\t最后喂食\t距今\t最后收获\t距今")
+		for _, colony := range timing.Colonies {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", colony.ColonyOrder, colony.Identifier, colony.ColonyType,
+				formatTimingTime(colony.FedAt, timing.Location), formatSince(colony.FedAt, colony.SinceFed),
+				formatTimingTime(colony.HarvestedAt, timing.Location), formatSince(colony.HarvestedAt, colony.SinceHarvested))
+		}
+
+		neverFed := timing.NeverFed()
+		fmt.Fprintf(tw, "\n从来没有喂过的星球(%v个)\n", len(neverFed))
+		for _, colony := range neverFed {
+			fmt.Fprintf(tw, "  #%v %v\n", colony.ColonyOrder, colony.Identifier)
+		}
+	}
+	if view == TIMING_HOURS || view == TIMING_ALL {
+		// 柱子最长50个字符
+		busiest := 1
+		for _, hour := range timing.Hours {
+			if hour.Fed+hour.Harvested > busiest {
+				busiest = hour.Fed + hour.Harvested
+			}
+		}
+		fmt.Fprintf(tw, "\n小时(%v)\t喂食\t收获\t\n", timing.Location)
+		for _, hour := range timing.Hours {
+			width := (hour.Fed + hour.Harvested) * 50 / busiest
+			fmt.Fprintf(tw, "%02d\t%v\t%v\t%v\n", hour.Hour, hour.Fed, hour.Harvested, strings.Repeat("#", width))
+		}
+	}
+	return tw.Flush()
+}
+
+func WriteTimingCSV(w io.Writer, view string, timing Timing) error {
+	writer := csv.NewWriter(w)
+	switch view {
+	case TIMING_COLONIES:
+		writer.Write([]string{"colony_order", "identifier", "colony_type", "fed_at", "since_fed_seconds", "harvested_at", "since_harvested_seconds"})
+		for _, colony := range timing.Colonies {
+			writer.Write([]string{
+				strconv.Itoa(colony.ColonyOrder),
+				colony.Identifier,
+				colony.ColonyType,
+				formatTimingTime(colony.FedAt, timing.Location),
+				formatSeconds(colony.FedAt, colony.SinceFed),
+				formatTimingTime(colony.HarvestedAt, timing.Location),
+				formatSeconds(colony.HarvestedAt, colony.SinceHarvested),
+			})
+		}
+	case TIMING_HOURS:
+		writer.Write([]string{"hour", "fed", "harvested"})
+		for _, hour := range timing.Hours {
+			writer.Write([]string{strconv.Itoa(hour.Hour), strconv.Itoa(hour.Fed), strconv.Itoa(hour.Harvested)})
+		}
+	default:
+		return fmt.Errorf("csv只能输出colonies或者hours, 不支持[%v]", view)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatTimingTime(t time.Time, location *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(location).Format("2006-01-02 15:04:05")
+}
+
+func formatSince(t time.Time, since time.Duration) string {
+	if t.IsZero() {
+		return "从来没有"
+	}
+	return (since / time.Second * time.Second).String()
+}
+
+func formatSeconds(t time.Time, since time.Duration) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(int64(since/time.Second), 10)
+}
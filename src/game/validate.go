@@ -0,0 +1,307 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// 违反的规则和对应的JSON路径, 比如 $.data.colonies[3].colony_order
+type Violation struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type Rule struct {
+	Name        string
+	Description string
+	check       func(data *GameData) []Violation
+}
+
+// 调整顺序之类的修改很容易把文件改坏, 使用之前先检查一遍
+var Rules = []Rule{
+	{"colony-order-duplicate", "colony_order不能重复", checkColonyOrderDuplicate},
+	{"colony-order-range", "colony_order在1到星球数量之间", checkColonyOrderRange},
+	{"colony-type", "colony_type只能是planet或者replicator", checkColonyType},
+	{"fed-times-length", "fed_times的数量和星球数量一致", checkFedTimesLength},
+	{"harvested-times-length", "harvested_times的数量和星球数量一致", checkHarvestedTimesLength},
+	{"identifier-empty", "identifier不能为空", checkIdentifierEmpty},
+	{"satellite-colony-missing", "放置了的卫星必须在某个星球上", checkSatelliteColonyMissing},
+	{"satellite-unknown", "星球上的卫星必须在satellites里", checkSatelliteUnknown},
+	{"satellite-duplicate", "一个卫星只能在一个星球上", checkSatelliteDuplicate},
+	{"satellite-colony-conflict", "同一个colony_id的卫星必须在同一个星球上", checkSatelliteColonyConflict},
+	{"mission-state", "任务不能既完成又放弃", checkMissionState},
+	{"achievement-progress", "成就进度在0到1之间, 完成的成就进度是1", checkAchievementProgress},
+}
+
+func FindRule(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// rules为空的话检查所有的规则
+func Validate(data *GameData, rules ...Rule) []Violation {
+	if len(rules) == 0 {
+		rules = Rules
+	}
+
+	violations := []Violation{}
+	for _, rule := range rules {
+		for _, violation := range rule.check(data) {
+			violation.Rule = rule.Name
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+func colonyPath(index int, field string) string {
+	return fmt.Sprintf("$.data.colonies[%v].%v", index, field)
+}
+
+func satellitePath(index int, field string) string {
+	return fmt.Sprintf("$.data.satellites[%v].%v", index, field)
+}
+
+func checkColonyOrderDuplicate(data *GameData) []Violation {
+	violations := []Violation{}
+	first := make(map[int]int)
+	for i, colony := range data.Colonies {
+		if j, ok := first[colony.ColonyOrder]; ok {
+			violations = append(violations, Violation{
+				Path:    colonyPath(i, "colony_order"),
+				Message: fmt.Sprintf("colony_order[%v]和colonies[%v]重复", colony.ColonyOrder, j),
+			})
+			continue
+		}
+		first[colony.ColonyOrder] = i
+	}
+	return violations
+}
+
+func checkColonyOrderRange(data *GameData) []Violation {
+	violations := []Violation{}
+	for i, colony := range data.Colonies {
+		if colony.ColonyOrder < 1 || colony.ColonyOrder > len(data.Colonies) {
+			violations = append(violations, Violation{
+				Path:    colonyPath(i, "colony_order"),
+				Message: fmt.Sprintf("colony_order[%v]不在1到%v之间", colony.ColonyOrder, len(data.Colonies)),
+			})
+		}
+	}
+	return violations
+}
+
+func checkColonyType(data *GameData) []Violation {
+	violations := []Violation{}
+	for i, colony := range data.Colonies {
+		if colony.ColonyType != COLONY_PLANET && colony.ColonyType != COLONY_REPLICATOR {
+			violations = append(violations, Violation{
+				Path:    colonyPath(i, "colony_type"),
+				Message: fmt.Sprintf("不认识的colony_type[%v]", colony.ColonyType),
+			})
+		}
+	}
+	return violations
+}
+
+func checkTimesLength(field string, times []time.Time, colonies int) []Violation {
+	if len(times) == colonies {
+		return nil
+	}
+	return []Violation{{
+		Path:    "$.data." + field,
+		Message: fmt.Sprintf("有%v个时间, 但是有%v个星球", len(times), colonies),
+	}}
+}
+
+func checkFedTimesLength(data *GameData) []Violation {
+	return checkTimesLength("fed_times", data.FedTimes, len(data.Colonies))
+}
+
+func checkHarvestedTimesLength(data *GameData) []Violation {
+	return checkTimesLength("harvested_times", data.HarvestedTimes, len(data.Colonies))
+}
+
+func checkIdentifierEmpty(data *GameData) []Violation {
+	violations := []Violation{}
+	empty := func(path string) {
+		violations = append(violations, Violation{Path: path, Message: "identifier是空的"})
+	}
+	for i, colony := range data.Colonies {
+		if colony.Identifier == "" {
+			empty(colonyPath(i, "identifier"))
+		}
+		for j, satellite := range colony.Satellites {
+			if satellite.Identifier == "" {
+				empty(colonyPath(i, fmt.Sprintf("satellites[%v].identifier", j)))
+			}
+		}
+	}
+	for i, satellite := range data.Satellites {
+		if satellite.Identifier == "" {
+			empty(satellitePath(i, "identifier"))
+		}
+	}
+	for i, mission := range data.Missions {
+		if mission.Identifier == "" {
+			empty(fmt.Sprintf("$.data.missions[%v].identifier", i))
+		}
+	}
+	for i, spaceship := range data.Spaceships {
+		if spaceship.Identifier == "" {
+			empty(fmt.Sprintf("$.data.spaceships[%v].identifier", i))
+		}
+	}
+	for i, achievement := range data.Achievements {
+		if achievement.Identifier == "" {
+			empty(fmt.Sprintf("$.data.achievements[%v].identifier", i))
+		}
+	}
+	return violations
+}
+
+// 卫星的名字对应所在星球的下标
+func embeddedSatellites(data *GameData) map[string]int {
+	colonies := make(map[string]int)
+	for i, colony := range data.Colonies {
+		for _, satellite := range colony.Satellites {
+			colonies[satellite.Identifier] = i
+		}
+	}
+	return colonies
+}
+
+// colony_id是服务器上星球的id, 文件里的星球没有id, 只能通过星球上的卫星对应起来
+func checkSatelliteColonyMissing(data *GameData) []Violation {
+	violations := []Violation{}
+	colonies := embeddedSatellites(data)
+	for i, satellite := range data.Satellites {
+		if _, ok := colonies[satellite.Identifier]; satellite.ColonyId != 0 && !ok {
+			violations = append(violations, Violation{
+				Path:    satellitePath(i, "colony_id"),
+				Message: fmt.Sprintf("卫星[%v]的colony_id是%v, 但是不在任何星球上", satellite.Identifier, satellite.ColonyId),
+			})
+		}
+	}
+	return violations
+}
+
+func checkSatelliteUnknown(data *GameData) []Violation {
+	violations := []Violation{}
+	satellites := make(map[string]Satellite)
+	for _, satellite := range data.Satellites {
+		satellites[satellite.Identifier] = satellite
+	}
+	for i, colony := range data.Colonies {
+		for j, mini := range colony.Satellites {
+			path := colonyPath(i, fmt.Sprintf("satellites[%v].identifier", j))
+			satellite, ok := satellites[mini.Identifier]
+			if !ok {
+				violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("卫星[%v]不在satellites里", mini.Identifier)})
+			} else if satellite.ColonyId == 0 {
+				violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("卫星[%v]在satellites里是没有放置的", mini.Identifier)})
+			}
+		}
+	}
+	return violations
+}
+
+func checkSatelliteDuplicate(data *GameData) []Violation {
+	violations := []Violation{}
+	first := make(map[string]int)
+	for i, colony := range data.Colonies {
+		for j, satellite := range colony.Satellites {
+			if k, ok := first[satellite.Identifier]; ok {
+				violations = append(violations, Violation{
+					Path:    colonyPath(i, fmt.Sprintf("satellites[%v].identifier", j)),
+					Message: fmt.Sprintf("卫星[%v]已经在colonies[%v]上", satellite.Identifier, k),
+				})
+				continue
+			}
+			first[satellite.Identifier] = i
+		}
+	}
+	return violations
+}
+
+func checkSatelliteColonyConflict(data *GameData) []Violation {
+	violations := []Violation{}
+	colonies := embeddedSatellites(data)
+	byColonyId := make(map[int]int)
+	byColony := make(map[int]int)
+	for i, satellite := range data.Satellites {
+		colony, ok := colonies[satellite.Identifier]
+		if satellite.ColonyId == 0 || !ok {
+			continue
+		}
+		if other, ok := byColonyId[satellite.ColonyId]; ok && other != colony {
+			violations = append(violations, Violation{
+				Path:    satellitePath(i, "colony_id"),
+				Message: fmt.Sprintf("colony_id[%v]的卫星分别在colonies[%v]和colonies[%v]上", satellite.ColonyId, other, colony),
+			})
+			continue
+		}
+		if other, ok := byColony[colony]; ok && other != satellite.ColonyId {
+			violations = append(violations, Violation{
+				Path:    satellitePath(i, "colony_id"),
+				Message: fmt.Sprintf("colonies[%v]上的卫星的colony_id分别是%v和%v", colony, other, satellite.ColonyId),
+			})
+			continue
+		}
+		byColonyId[satellite.ColonyId] = colony
+		byColony[colony] = satellite.ColonyId
+	}
+	return violations
+}
+
+func checkMissionState(data *GameData) []Violation {
+	violations := []Violation{}
+	for i, mission := range data.Missions {
+		if mission.Completed && mission.Aborted {
+			violations = append(violations, Violation{
+				Path:    fmt.Sprintf("$.data.missions[%v]", i),
+				Message: fmt.Sprintf("任务[%v]既完成又放弃", mission.Identifier),
+			})
+		}
+	}
+	return violations
+}
+
+func checkAchievementProgress(data *GameData) []Violation {
+	violations := []Violation{}
+	for i, achievement := range data.Achievements {
+		path := fmt.Sprintf("$.data.achievements[%v].progress", i)
+		if achievement.Progress < 0 || achievement.Progress > 1 {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("进度[%v]不在0到1之间", achievement.Progress)})
+		} else if achievement.Completed && achievement.Progress != 1 {
+			violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("成就[%v]已经完成, 但是进度是%v", achievement.Identifier, achievement.Progress)})
+		}
+	}
+	return violations
+}
+
+func WriteViolations(w io.Writer, format string, violations []Violation) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "规则\t路径\t说明")
+		for _, violation := range violations {
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", violation.Rule, violation.Path, violation.Message)
+		}
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(violations)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
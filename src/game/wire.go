@@ -0,0 +1,261 @@
+package game
+
+// 每个类型对应一个wire结构, 字段的顺序和game.json一样, 字段都是指向模型的指针,
+// 序列化和反序列化共用同一个结构; 原始的JSON保存在raw里, 见original
+
+type responseWire struct {
+	Success *bool     `json:"success"`
+	Data    *GameData `json:"data"`
+}
+
+func (this *GameResponse) wire() *responseWire {
+	return &responseWire{Success: &this.Success, Data: &this.Data}
+}
+
+func (this GameResponse) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *GameResponse) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type gameDataWire struct {
+	Coins          *int64         `json:"coins"`
+	Cubes          *int           `json:"cubes"`
+	Energy         *int           `json:"energy"`
+	FedTimes       *unixTimes     `json:"fed_times"`
+	Food           *int           `json:"food"`
+	HarvestedTimes *unixTimes     `json:"harvested_times"`
+	Level          *int           `json:"level"`
+	Ltvalue        *float64       `json:"ltvalue"`
+	Population     *int           `json:"population"`
+	Spaceship      *string        `json:"spaceship"`
+	Colonies       *[]Colony      `json:"colonies"`
+	Satellites     *[]Satellite   `json:"satellites"`
+	Missions       *[]Mission     `json:"missions"`
+	Activities     *[]Activity    `json:"activities"`
+	Spaceships     *[]Spaceship   `json:"spaceships"`
+	Achievements   *[]Achievement `json:"achievements"`
+	Messages       *[]int         `json:"messages"`
+}
+
+func (this *GameData) wire() *gameDataWire {
+	return &gameDataWire{
+		Coins:          &this.Coins,
+		Cubes:          &this.Cubes,
+		Energy:         &this.Energy,
+		FedTimes:       (*unixTimes)(&this.FedTimes),
+		Food:           &this.Food,
+		HarvestedTimes: (*unixTimes)(&this.HarvestedTimes),
+		Level:          &this.Level,
+		Ltvalue:        &this.Ltvalue,
+		Population:     &this.Population,
+		Spaceship:      &this.Spaceship,
+		Colonies:       &this.Colonies,
+		Satellites:     &this.Satellites,
+		Missions:       &this.Missions,
+		Activities:     &this.Activities,
+		Spaceships:     &this.Spaceships,
+		Achievements:   &this.Achievements,
+		Messages:       &this.Messages,
+	}
+}
+
+func (this GameData) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *GameData) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type achievementWire struct {
+	Completed  *bool     `json:"completed"`
+	Identifier *string   `json:"identifier"`
+	Progress   *float64  `json:"progress"`
+	UpdatedAt  *jsonTime `json:"updated_at"`
+}
+
+func (this *Achievement) wire() *achievementWire {
+	return &achievementWire{
+		Completed:  &this.Completed,
+		Identifier: &this.Identifier,
+		Progress:   &this.Progress,
+		UpdatedAt:  (*jsonTime)(&this.UpdatedAt),
+	}
+}
+
+func (this Achievement) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Achievement) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type spaceshipWire struct {
+	Identifier *string   `json:"identifier"`
+	UpdatedAt  *jsonTime `json:"updated_at"`
+}
+
+func (this *Spaceship) wire() *spaceshipWire {
+	return &spaceshipWire{Identifier: &this.Identifier, UpdatedAt: (*jsonTime)(&this.UpdatedAt)}
+}
+
+func (this Spaceship) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Spaceship) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type activityWire struct {
+	Date      *jsonTime `json:"date"`
+	Running   *int      `json:"running"`
+	UpdatedAt *jsonTime `json:"updated_at"`
+	Walking   *int      `json:"walking"`
+}
+
+func (this *Activity) wire() *activityWire {
+	return &activityWire{
+		Date:      (*jsonTime)(&this.Date),
+		Running:   &this.Running,
+		UpdatedAt: (*jsonTime)(&this.UpdatedAt),
+		Walking:   &this.Walking,
+	}
+}
+
+func (this Activity) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Activity) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type missionWire struct {
+	Aborted    *bool     `json:"aborted"`
+	Completed  *bool     `json:"completed"`
+	Identifier *string   `json:"identifier"`
+	ResourceA  *int      `json:"resource_a"`
+	ResourceB  *int      `json:"resource_b"`
+	ResourceC  *int      `json:"resource_c"`
+	UpdatedAt  *jsonTime `json:"updated_at"`
+}
+
+func (this *Mission) wire() *missionWire {
+	return &missionWire{
+		Aborted:    &this.Aborted,
+		Completed:  &this.Completed,
+		Identifier: &this.Identifier,
+		ResourceA:  &this.ResourceA,
+		ResourceB:  &this.ResourceB,
+		ResourceC:  &this.ResourceC,
+		UpdatedAt:  (*jsonTime)(&this.UpdatedAt),
+	}
+}
+
+func (this Mission) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Mission) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type colonyWire struct {
+	Category     *string          `json:"category"`
+	ColonyOrder  *int             `json:"colony_order"`
+	ColonyType   *string          `json:"colony_type"`
+	Completed    *bool            `json:"completed"`
+	DiscoveredAt *jsonTime        `json:"discovered_at"`
+	GalaxyId     *int             `json:"galaxy_id"`
+	Identifier   *string          `json:"identifier"`
+	Level        *int             `json:"level"`
+	OffsetX      *float64         `json:"offset_x"`
+	OffsetY      *float64         `json:"offset_y"`
+	ProcessTime  *float64         `json:"process_time"`
+	ProcessedAt  *jsonTime        `json:"processed_at"`
+	UpdatedAt    *jsonTime        `json:"updated_at"`
+	ZPosition    *float64         `json:"z_position"`
+	ZRotation    *float64         `json:"z_rotation"`
+	Satellites   *[]MiniSatellite `json:"satellites"`
+}
+
+func (this *Colony) wire() *colonyWire {
+	return &colonyWire{
+		Category:     &this.Category,
+		ColonyOrder:  &this.ColonyOrder,
+		ColonyType:   &this.ColonyType,
+		Completed:    &this.Completed,
+		DiscoveredAt: (*jsonTime)(&this.DiscoveredAt),
+		GalaxyId:     &this.GalaxyId,
+		Identifier:   &this.Identifier,
+		Level:        &this.Level,
+		OffsetX:      &this.OffsetX,
+		OffsetY:      &this.OffsetY,
+		ProcessTime:  &this.ProcessTime,
+		ProcessedAt:  (*jsonTime)(&this.ProcessedAt),
+		UpdatedAt:    (*jsonTime)(&this.UpdatedAt),
+		ZPosition:    &this.ZPosition,
+		ZRotation:    &this.ZRotation,
+		Satellites:   &this.Satellites,
+	}
+}
+
+func (this Colony) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Colony) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type miniSatelliteWire struct {
+	Identifier *string `json:"identifier"`
+}
+
+func (this *MiniSatellite) wire() *miniSatelliteWire {
+	return &miniSatelliteWire{Identifier: &this.Identifier}
+}
+
+func (this MiniSatellite) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *MiniSatellite) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
+
+type satelliteWire struct {
+	ColonyId   *int      `json:"colony_id"`
+	Identifier *string   `json:"identifier"`
+	UpdatedAt  *jsonTime `json:"updated_at"`
+}
+
+func (this *Satellite) wire() *satelliteWire {
+	return &satelliteWire{
+		ColonyId:   &this.ColonyId,
+		Identifier: &this.Identifier,
+		UpdatedAt:  (*jsonTime)(&this.UpdatedAt),
+	}
+}
+
+func (this Satellite) MarshalJSON() ([]byte, error) {
+	return marshalObject(this.wire(), this.raw)
+}
+
+func (this *Satellite) UnmarshalJSON(b []byte) (err error) {
+	this.raw, err = unmarshalObject(b, this.wire(), this.wire())
+	return err
+}
@@ -0,0 +1,278 @@
+package gamedb
+
+import (
+	"database/sql"
+	"fmt"
+	"game"
+	"time"
+)
+
+// 把游戏数据导入SQLite, 方便直接用SQL查询, 比如:
+//
+//	SELECT a.name, c.identifier, c.level FROM colonies c
+//	JOIN snapshots s ON s.id = c.snapshot_id JOIN accounts a ON a.id = s.account_id
+//	WHERE c.category = 'animal' AND c.level < 5
+//
+// 同一个账号同一个时间的游戏数据只有一份, 重复导入会替换原来的
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS accounts (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	)`,
+	`CREATE TABLE IF NOT EXISTS snapshots (
+		id INTEGER PRIMARY KEY,
+		account_id INTEGER NOT NULL REFERENCES accounts(id),
+		taken_at TEXT NOT NULL,
+		coins INTEGER,
+		cubes INTEGER,
+		energy INTEGER,
+		food INTEGER,
+		population INTEGER,
+		level INTEGER,
+		ltvalue REAL,
+		spaceship TEXT,
+		UNIQUE (account_id, taken_at)
+	)`,
+	`CREATE TABLE IF NOT EXISTS colonies (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		colony_order INTEGER,
+		identifier TEXT,
+		colony_type TEXT,
+		category TEXT,
+		level INTEGER,
+		completed INTEGER,
+		galaxy_id INTEGER,
+		offset_x REAL,
+		offset_y REAL,
+		z_position REAL,
+		z_rotation REAL,
+		process_time REAL,
+		discovered_at TEXT,
+		processed_at TEXT,
+		updated_at TEXT,
+		fed_at TEXT,
+		harvested_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS satellites (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		identifier TEXT,
+		colony_id INTEGER,
+		colony_position INTEGER,
+		updated_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS missions (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		identifier TEXT,
+		completed INTEGER,
+		aborted INTEGER,
+		resource_a INTEGER,
+		resource_b INTEGER,
+		resource_c INTEGER,
+		updated_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS achievements (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		identifier TEXT,
+		completed INTEGER,
+		progress REAL,
+		updated_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS spaceships (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		identifier TEXT,
+		updated_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS activities (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots(id),
+		position INTEGER NOT NULL,
+		date TEXT,
+		running INTEGER,
+		walking INTEGER,
+		updated_at TEXT,
+		PRIMARY KEY (snapshot_id, position)
+	)`,
+}
+
+// 删除旧的游戏数据时要清空的表
+var children = []string{"colonies", "satellites", "missions", "achievements", "spaceships", "activities"}
+
+type DB struct {
+	db *sql.DB
+}
+
+// 文件不存在的话会新建, 表也会自动建好
+func Open(path string) (*DB, error) {
+	if !SUPPORTED {
+		return nil, fmt.Errorf("编译的时候没有加-tags sqlite, 不支持SQLite")
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("建表失败: %v", err)
+		}
+	}
+	return &DB{db: db}, nil
+}
+
+func (this *DB) Close() error {
+	return this.db.Close()
+}
+
+// 导入一份游戏数据, 已经有同一个账号同一个时间的话先删掉; 返回是否替换了原来的
+func (this *DB) Export(account string, at time.Time, data *game.GameData) (replaced bool, err error) {
+	tx, err := this.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO accounts (name) VALUES (?)`, account); err != nil {
+		return false, err
+	}
+	var accountId int64
+	if err = tx.QueryRow(`SELECT id FROM accounts WHERE name = ?`, account).Scan(&accountId); err != nil {
+		return false, err
+	}
+
+	takenAt := formatTime(at)
+	var oldId int64
+	err = tx.QueryRow(`SELECT id FROM snapshots WHERE account_id = ? AND taken_at = ?`, accountId, takenAt).Scan(&oldId)
+	switch {
+	case err == sql.ErrNoRows:
+		err = nil
+	case err != nil:
+		return false, err
+	default:
+		replaced = true
+		for _, table := range children {
+			if _, err = tx.Exec(`DELETE FROM `+table+` WHERE snapshot_id = ?`, oldId); err != nil {
+				return false, err
+			}
+		}
+		if _, err = tx.Exec(`DELETE FROM snapshots WHERE id = ?`, oldId); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO snapshots (account_id, taken_at, coins, cubes, energy, food, population, level, ltvalue, spaceship)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountId, takenAt, data.Coins, data.Cubes, data.Energy, data.Food, data.Population, data.Level, data.Ltvalue, data.Spaceship)
+	if err != nil {
+		return false, err
+	}
+	snapshotId, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+
+	err = insertChildren(tx, snapshotId, data)
+	return replaced, err
+}
+
+func insertChildren(tx *sql.Tx, snapshotId int64, data *game.GameData) error {
+	// 卫星在哪个星球上只能从星球里的卫星看出来
+	satelliteColonies := make(map[string]int)
+	for i, colony := range data.Colonies {
+		_, err := tx.Exec(`INSERT INTO colonies (snapshot_id, position, colony_order, identifier, colony_type, category, level, completed, galaxy_id,
+			offset_x, offset_y, z_position, z_rotation, process_time, discovered_at, processed_at, updated_at, fed_at, harvested_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			snapshotId, i, colony.ColonyOrder, colony.Identifier, colony.ColonyType, nullString(colony.Category), colony.Level, colony.Completed, colony.GalaxyId,
+			colony.OffsetX, colony.OffsetY, colony.ZPosition, colony.ZRotation, colony.ProcessTime,
+			nullTime(colony.DiscoveredAt), nullTime(colony.ProcessedAt), nullTime(colony.UpdatedAt), nullTime(data.FedAt(i)), nullTime(data.HarvestedAt(i)))
+		if err != nil {
+			return fmt.Errorf("导入星球[%v]失败: %v", colony.Identifier, err)
+		}
+		for _, satellite := range colony.Satellites {
+			satelliteColonies[satellite.Identifier] = i
+		}
+	}
+
+	for i, satellite := range data.Satellites {
+		var colonyId, colonyPosition interface{}
+		if satellite.ColonyId != 0 {
+			colonyId = satellite.ColonyId
+		}
+		if position, ok := satelliteColonies[satellite.Identifier]; ok {
+			colonyPosition = position
+		}
+		_, err := tx.Exec(`INSERT INTO satellites (snapshot_id, position, identifier, colony_id, colony_position, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			snapshotId, i, satellite.Identifier, colonyId, colonyPosition, nullTime(satellite.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("导入卫星[%v]失败: %v", satellite.Identifier, err)
+		}
+	}
+
+	for i, mission := range data.Missions {
+		_, err := tx.Exec(`INSERT INTO missions (snapshot_id, position, identifier, completed, aborted, resource_a, resource_b, resource_c, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			snapshotId, i, mission.Identifier, mission.Completed, mission.Aborted, mission.ResourceA, mission.ResourceB, mission.ResourceC, nullTime(mission.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("导入任务[%v]失败: %v", mission.Identifier, err)
+		}
+	}
+
+	for i, achievement := range data.Achievements {
+		_, err := tx.Exec(`INSERT INTO achievements (snapshot_id, position, identifier, completed, progress, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			snapshotId, i, achievement.Identifier, achievement.Completed, achievement.Progress, nullTime(achievement.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("导入成就[%v]失败: %v", achievement.Identifier, err)
+		}
+	}
+
+	for i, spaceship := range data.Spaceships {
+		_, err := tx.Exec(`INSERT INTO spaceships (snapshot_id, position, identifier, updated_at) VALUES (?, ?, ?, ?)`,
+			snapshotId, i, spaceship.Identifier, nullTime(spaceship.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("导入飞船[%v]失败: %v", spaceship.Identifier, err)
+		}
+	}
+
+	for i, activity := range data.Activities {
+		_, err := tx.Exec(`INSERT INTO activities (snapshot_id, position, date, running, walking, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			snapshotId, i, nullTime(activity.Date), activity.Running, activity.Walking, nullTime(activity.UpdatedAt))
+		if err != nil {
+			return fmt.Errorf("导入活动[%v]失败: %v", formatTime(activity.Date), err)
+		}
+	}
+
+	return nil
+}
+
+// 和game.json一样用UTC, SQLite的日期函数可以直接使用
+func formatTime(t time.Time) string {
+	return t.UTC().Format(game.TimeLayout)
+}
+
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return formatTime(t)
+}
+
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
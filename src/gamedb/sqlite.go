@@ -0,0 +1,9 @@
+//go:build sqlite
+// +build sqlite
+
+package gamedb
+
+import _ "github.com/mattn/go-sqlite3"
+
+// go-sqlite3要用cgo, 交叉编译的时候关掉cgo, 所以只有加上-tags sqlite才会带上
+const SUPPORTED = true
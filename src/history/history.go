@@ -0,0 +1,183 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+const HistoryKey = "epic:history"
+
+// 只保留最近的这么多条记录, Query每次从Redis读取PageSize条
+const (
+	MaxRecords = 50000
+	PageSize   = 1000
+)
+
+// 一次帮飞的记录
+type Record struct {
+	Account       string    `json:"account"`
+	PlayerId      int       `json:"player_id"`
+	EpicId        int       `json:"epic_id"`
+	EpicName      string    `json:"epic_name"`
+	FleetId       int       `json:"fleet_id"`
+	FleetName     string    `json:"fleet_name"`
+	Captain       string    `json:"captain"`
+	JoinedAt      time.Time `json:"joined_at"`
+	LeftAt        time.Time `json:"left_at"`
+	Comments      []string  `json:"comments"`
+	LeaveAttempts int       `json:"leave_attempts"`
+	LeaveOk       bool      `json:"leave_ok"`
+}
+
+// 空的条件表示不过滤, Epic可以是传说的ID或者名字
+type Filter struct {
+	Account string
+	Captain string
+	Epic    string
+	From    time.Time
+	To      time.Time
+}
+
+func Save(redis *goredis.Client, record *Record) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := redis.RPush(HistoryKey, string(b)).Err(); err != nil {
+		return err
+	}
+	return redis.LTrim(HistoryKey, -MaxRecords, -1).Err()
+}
+
+func Query(redis *goredis.Client, filter Filter) ([]Record, error) {
+	records := []Record{}
+	for start := int64(0); ; start += PageSize {
+		values, err := redis.LRange(HistoryKey, start, start+PageSize-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			var record Record
+			if err := json.Unmarshal([]byte(value), &record); err != nil {
+				return nil, fmt.Errorf("解析帮飞记录失败: %v", err)
+			}
+			if filter.Match(record) {
+				records = append(records, record)
+			}
+		}
+		if len(values) < PageSize {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+func (this Filter) Match(record Record) bool {
+	if this.Account != "" && this.Account != record.Account && this.Account != strconv.Itoa(record.PlayerId) {
+		return false
+	}
+	if this.Captain != "" && this.Captain != record.Captain {
+		return false
+	}
+	if this.Epic != "" && this.Epic != record.EpicName && this.Epic != strconv.Itoa(record.EpicId) {
+		return false
+	}
+	if !this.From.IsZero() && record.JoinedAt.Before(this.From) {
+		return false
+	}
+	if !this.To.IsZero() && !record.JoinedAt.Before(this.To) {
+		return false
+	}
+
+	return true
+}
+
+func Write(w io.Writer, format string, records []Record) error {
+	switch format {
+	case "table":
+		return WriteTable(w, records)
+	case "csv":
+		return WriteCSV(w, records)
+	case "json":
+		return WriteJSON(w, records)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
+
+func WriteTable(w io.Writer, records []Record) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "账号\t传说\t舰队\t舰长\t加入时间\t离开时间\t留言\t离队尝试\t离队结果")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%v\t%v:%v\t%v:%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			record.Account,
+			record.EpicName, record.EpicId,
+			record.FleetName, record.FleetId,
+			record.Captain,
+			formatTime(record.JoinedAt),
+			formatTime(record.LeftAt),
+			len(record.Comments),
+			record.LeaveAttempts,
+			leaveResult(record),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"account", "player_id", "epic_id", "epic_name", "fleet_id", "fleet_name", "captain", "joined_at", "left_at", "comments", "leave_attempts", "leave_ok"})
+	for _, record := range records {
+		cw.Write([]string{
+			record.Account,
+			strconv.Itoa(record.PlayerId),
+			strconv.Itoa(record.EpicId),
+			record.EpicName,
+			strconv.Itoa(record.FleetId),
+			record.FleetName,
+			record.Captain,
+			formatTime(record.JoinedAt),
+			formatTime(record.LeftAt),
+			strings.Join(record.Comments, "\n"),
+			strconv.Itoa(record.LeaveAttempts),
+			strconv.FormatBool(record.LeaveOk),
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func WriteJSON(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(records)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func leaveResult(record Record) string {
+	if record.LeftAt.IsZero() {
+		return "未离开"
+	}
+	if record.LeaveOk {
+		return "成功"
+	}
+	return "失败"
+}
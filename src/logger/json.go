@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+// 所有写日志的后端共用一把锁, 保证每一行是完整的
+var mu sync.Mutex
+
+// 账号日志文件不需要颜色
+var plainFormat = logging.MustStringFormatter(
+	"%{time:2006-01-02 15:04:05.000} %{shortfile} ▶ %{level:.4s} %{id:03x} %{message}",
+)
+
+// JSON格式的一行日志
+type entry struct {
+	Time     string `json:"time"`
+	Level    string `json:"level"`
+	Module   string `json:"module"`
+	File     string `json:"file,omitempty"`
+	Message  string `json:"message"`
+	Account  string `json:"account,omitempty"`
+	PlayerId int    `json:"player_id,omitempty"`
+	FleetId  int    `json:"fleet_id,omitempty"`
+	EpicId   int    `json:"epic_id,omitempty"`
+}
+
+type jsonBackend struct {
+	w io.Writer
+}
+
+func (this *jsonBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return writeJSON(this.w, calldepth+1, rec)
+}
+
+func writeJSON(w io.Writer, calldepth int, rec *logging.Record) error {
+	fields, message := split(rec)
+	e := entry{
+		Time:     rec.Time.Format(time.RFC3339Nano),
+		Level:    rec.Level.String(),
+		Module:   rec.Module,
+		Message:  message,
+		Account:  fields.Account,
+		PlayerId: fields.PlayerId,
+		FleetId:  fields.FleetId,
+		EpicId:   fields.EpicId,
+	}
+	if _, file, line, ok := runtime.Caller(calldepth + 1); ok {
+		e.File = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func plainFormatter(calldepth int, rec *logging.Record) string {
+	buf := &bytes.Buffer{}
+	plainFormat.Format(calldepth+1, rec, buf)
+	return buf.String()
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/op/go-logging"
+)
+
+// 所有程序共用的控制台格式
+var ConsoleFormat = logging.MustStringFormatter(
+	"%{color}%{time:15:04:05.000} %{shortfile} ▶ %{level:.4s} %{id:03x}%{color:reset} %{message}",
+)
+
+const (
+	FORMAT_CONSOLE = "console"
+	FORMAT_JSON    = "json"
+)
+
+// 配置文件里的[Log]
+type Config struct {
+	// console或者json, 默认console
+	Format string
+	// 默认的日志级别, 默认DEBUG
+	Level string
+	// 每个模块单独的级别, 比如 {scheduler = "WARNING", notify = "INFO"}
+	Modules map[string]string
+	// 不为空的话每个账号的日志另外写到这个目录下的「账号名.log」
+	AccountDir string
+	// 单个账号日志文件最大多少MB, 默认10MB
+	MaxSize int
+	// 保留多少个旧的账号日志文件, 默认5个
+	MaxBackups int
+}
+
+// 初始化所有模块的日志输出, 没有调用的话使用go-logging默认的输出
+func Setup(config Config) error {
+	var backend logging.Backend
+	switch config.Format {
+	case "", FORMAT_CONSOLE:
+		backend = logging.NewBackendFormatter(logging.NewLogBackend(os.Stderr, "", 0), ConsoleFormat)
+	case FORMAT_JSON:
+		backend = &jsonBackend{w: os.Stderr}
+	default:
+		return fmt.Errorf("日志格式[%v]有问题, 只支持console和json", config.Format)
+	}
+
+	if config.AccountDir != "" {
+		if err := os.MkdirAll(config.AccountDir, 0755); err != nil {
+			return fmt.Errorf("创建账号日志目录[%v]失败: %v", config.AccountDir, err)
+		}
+		accounts := &accountBackend{
+			dir:        config.AccountDir,
+			json:       config.Format == FORMAT_JSON,
+			maxSize:    int64(config.MaxSize) * 1024 * 1024,
+			maxBackups: config.MaxBackups,
+			writers:    make(map[string]*rotateWriter),
+		}
+		if accounts.maxSize <= 0 {
+			accounts.maxSize = 10 * 1024 * 1024
+		}
+		if accounts.maxBackups <= 0 {
+			accounts.maxBackups = 5
+		}
+		backend = &teeBackend{backends: []logging.Backend{backend, accounts}}
+	}
+
+	leveled := logging.AddModuleLevel(&redactBackend{backend: backend})
+	level := logging.DEBUG
+	if config.Level != "" {
+		l, err := logging.LogLevel(config.Level)
+		if err != nil {
+			return fmt.Errorf("日志级别[%v]有问题: %v", config.Level, err)
+		}
+		level = l
+	}
+	leveled.SetLevel(level, "")
+	for module, name := range config.Modules {
+		l, err := logging.LogLevel(name)
+		if err != nil {
+			return fmt.Errorf("模块[%v]的日志级别[%v]有问题: %v", module, name, err)
+		}
+		leveled.SetLevel(l, module)
+	}
+
+	logging.SetBackend(leveled)
+	return nil
+}
+
+// 日志里结构化的字段, JSON格式下单独输出, 控制台格式下显示在消息前面
+type Fields struct {
+	Account  string
+	PlayerId int
+	FleetId  int
+	EpicId   int
+}
+
+func (this Fields) String() string {
+	if this.Account == "" {
+		return ""
+	}
+	return fmt.Sprintf("「%v」", this.Account)
+}
+
+// 带着账号信息的Logger, 参数和fmt.Printf一样;
+// go-logging 2.0之后Error不再格式化, 所有模块都通过Logger输出, 不要直接用logging.MustGetLogger
+type Logger struct {
+	log    *logging.Logger
+	fields Fields
+}
+
+func New(module string, fields Fields) *Logger {
+	return &Logger{log: &logging.Logger{Module: module, ExtraCalldepth: 1}, fields: fields}
+}
+
+// 加上舰队和传说
+func (this *Logger) WithFleet(epicId int, fleetId int) *Logger {
+	fields := this.fields
+	fields.EpicId = epicId
+	fields.FleetId = fleetId
+
+	return &Logger{log: this.log, fields: fields}
+}
+
+func (this *Logger) Fields() Fields {
+	return this.fields
+}
+
+// 输出之后退出程序
+func (this *Logger) Fatal(format string, args ...interface{}) {
+	this.log.Criticalf(this.format(format), this.args(args)...)
+	os.Exit(1)
+}
+
+func (this *Logger) Critical(format string, args ...interface{}) {
+	this.log.Criticalf(this.format(format), this.args(args)...)
+}
+
+func (this *Logger) Error(format string, args ...interface{}) {
+	this.log.Errorf(this.format(format), this.args(args)...)
+}
+
+func (this *Logger) Warning(format string, args ...interface{}) {
+	this.log.Warningf(this.format(format), this.args(args)...)
+}
+
+func (this *Logger) Notice(format string, args ...interface{}) {
+	this.log.Noticef(this.format(format), this.args(args)...)
+}
+
+func (this *Logger) Info(format string, args ...interface{}) {
+	this.log.Infof(this.format(format), this.args(args)...)
+}
+
+func (this *Logger) Debug(format string, args ...interface{}) {
+	this.log.Debugf(this.format(format), this.args(args)...)
+}
+
+// 字段作为第一个参数传给后端, 后端再从消息里去掉
+func (this *Logger) format(format string) string {
+	return "%v" + format
+}
+
+func (this *Logger) args(args []interface{}) []interface{} {
+	return append([]interface{}{this.fields}, args...)
+}
+
+// 返回记录里的字段和去掉字段之后的消息
+func split(rec *logging.Record) (Fields, string) {
+	message := rec.Message()
+	if len(rec.Args) == 0 {
+		return Fields{}, message
+	}
+	fields, ok := rec.Args[0].(Fields)
+	if !ok {
+		return Fields{}, message
+	}
+
+	return fields, strings.TrimPrefix(message, fields.String())
+}
+
+// 同时写到多个后端
+type teeBackend struct {
+	backends []logging.Backend
+}
+
+func (this *teeBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	var lastErr error
+	for _, backend := range this.backends {
+		if err := backend.Log(level, calldepth+1, rec); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// 有账号的日志写到各自的文件里
+type accountBackend struct {
+	dir        string
+	json       bool
+	maxSize    int64
+	maxBackups int
+
+	writers map[string]*rotateWriter
+}
+
+func (this *accountBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	fields, _ := split(rec)
+	if fields.Account == "" {
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	w, ok := this.writers[fields.Account]
+	if !ok {
+		w = &rotateWriter{
+			path:       filepath.Join(this.dir, fields.Account+".log"),
+			maxSize:    this.maxSize,
+			maxBackups: this.maxBackups,
+		}
+		this.writers[fields.Account] = w
+	}
+
+	if this.json {
+		return writeJSON(w, calldepth+1, rec)
+	}
+	_, err := io.WriteString(w, plainFormatter(calldepth+1, rec)+"\n")
+	return err
+}
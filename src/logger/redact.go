@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/op/go-logging"
+)
+
+const REDACTED = "[REDACTED]"
+
+var secretsMu sync.RWMutex
+var secrets = make(map[string]bool)
+var replacer = strings.NewReplacer()
+
+// 登记需要在日志里隐藏的凭证, URL编码之后的形式也会隐藏
+func AddSecret(secret string) {
+	if len(secret) < 6 {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets[secret] = true
+	secrets[url.QueryEscape(secret)] = true
+
+	// 长的排在前面, 防止只替换了一部分
+	all := []string{}
+	for s := range secrets {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return len(all[i]) > len(all[j])
+	})
+	pairs := []string{}
+	for _, s := range all {
+		pairs = append(pairs, s, REDACTED)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// 把登记过的凭证替换成[REDACTED], 事件和通知也会用到
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	return replacer.Replace(s)
+}
+
+// 在格式化之前替换参数里的凭证, 所有的输出都不会出现凭证
+type redactBackend struct {
+	backend logging.Backend
+}
+
+func (this *redactBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	for i, arg := range rec.Args {
+		if _, ok := arg.(Fields); ok {
+			continue
+		}
+		s := fmt.Sprint(arg)
+		if redacted := Redact(s); redacted != s {
+			rec.Args[i] = redacted
+		}
+	}
+
+	return this.backend.Log(level, calldepth+1, rec)
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// 文件超过maxSize之后改名为 xxx.log.1, 原来的 xxx.log.1 改为 xxx.log.2, 以此类推
+type rotateWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func (this *rotateWriter) Write(p []byte) (int, error) {
+	if this.file == nil {
+		if err := this.open(); err != nil {
+			return 0, err
+		}
+	}
+	if this.size+int64(len(p)) > this.maxSize && this.size > 0 {
+		if err := this.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := this.file.Write(p)
+	this.size += int64(n)
+	return n, err
+}
+
+func (this *rotateWriter) open() error {
+	file, err := os.OpenFile(this.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	this.file = file
+	this.size = info.Size()
+	return nil
+}
+
+func (this *rotateWriter) rotate() error {
+	this.file.Close()
+	this.file = nil
+
+	os.Remove(fmt.Sprintf("%v.%v", this.path, this.maxBackups))
+	for i := this.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%v.%v", this.path, i), fmt.Sprintf("%v.%v", this.path, i+1))
+	}
+	if err := os.Rename(this.path, this.path+".1"); err != nil {
+		return err
+	}
+
+	return this.open()
+}
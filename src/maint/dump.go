@@ -0,0 +1,196 @@
+package maint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 导出的所有状态, 导入的时候按Key整个替换
+type Dump struct {
+	// 导出时的迁移版本
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Keys       []DumpKey `json:"keys"`
+}
+
+// 根据Type只有一个值有内容
+type DumpKey struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// 剩余的秒数, 0表示不过期
+	TTL int64 `json:"ttl,omitempty"`
+
+	String string            `json:"string,omitempty"`
+	Hash   map[string]string `json:"hash,omitempty"`
+	List   []string          `json:"list,omitempty"`
+	Set    []string          `json:"set,omitempty"`
+	ZSet   []ZMember         `json:"zset,omitempty"`
+}
+
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+func (this *Maint) Export(w io.Writer, patterns []string) error {
+	version, err := this.Version()
+	if err != nil {
+		return err
+	}
+	keys, err := this.keys(patterns)
+	if err != nil {
+		return err
+	}
+
+	dump := Dump{Version: version, ExportedAt: time.Now(), Keys: []DumpKey{}}
+	for _, key := range keys {
+		item, err := this.dumpKey(key)
+		if err != nil {
+			return fmt.Errorf("导出[%v]失败: %v", key, err)
+		}
+		if item != nil {
+			dump.Keys = append(dump.Keys, *item)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
+
+// Key在读取的过程中被删除的话返回nil
+func (this *Maint) dumpKey(key string) (*DumpKey, error) {
+	var err error
+	item := &DumpKey{Key: key}
+	if item.Type, err = this.redis.Type(key).Result(); err != nil {
+		return nil, err
+	}
+
+	switch item.Type {
+	case "none":
+		return nil, nil
+	case "string":
+		item.String, err = this.redis.Get(key).Result()
+	case "hash":
+		item.Hash, err = this.redis.HGetAllMap(key).Result()
+	case "list":
+		item.List, err = this.redis.LRange(key, 0, -1).Result()
+	case "set":
+		item.Set, err = this.redis.SMembers(key).Result()
+	case "zset":
+		var members []goredis.Z
+		if members, err = this.redis.ZRangeWithScores(key, 0, -1).Result(); err == nil {
+			for _, member := range members {
+				item.ZSet = append(item.ZSet, ZMember{Member: member.Member, Score: member.Score})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的类型[%v]", item.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err := this.redis.TTL(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		item.TTL = int64(ttl / time.Second)
+	}
+
+	return item, nil
+}
+
+// 导入的Key先删除再写入, 文件里没有的Key不会修改
+func (this *Maint) Import(r io.Reader) error {
+	var dump Dump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return fmt.Errorf("解析导出文件失败: %v", err)
+	}
+	if latest := LatestVersion(); dump.Version > latest {
+		return fmt.Errorf("导出文件的版本[%v]比当前程序支持的版本[%v]新", dump.Version, latest)
+	}
+
+	for _, item := range dump.Keys {
+		if err := this.restoreKey(item); err != nil {
+			return fmt.Errorf("导入[%v]失败: %v", item.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func (this *Maint) restoreKey(item DumpKey) error {
+	var write func() error
+	var length int
+	switch item.Type {
+	case "string":
+		length = len(item.String)
+		write = func() error {
+			return this.redis.Set(item.Key, item.String).Err()
+		}
+	case "hash":
+		length = len(item.Hash)
+		write = func() error {
+			for field, value := range item.Hash {
+				if err := this.redis.HSet(item.Key, field, value).Err(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case "list":
+		length = len(item.List)
+		write = func() error {
+			if len(item.List) == 0 {
+				return nil
+			}
+			return this.redis.RPush(item.Key, item.List...).Err()
+		}
+	case "set":
+		length = len(item.Set)
+		write = func() error {
+			if len(item.Set) == 0 {
+				return nil
+			}
+			return this.redis.SAdd(item.Key, item.Set...).Err()
+		}
+	case "zset":
+		length = len(item.ZSet)
+		write = func() error {
+			members := []goredis.Z{}
+			for _, member := range item.ZSet {
+				members = append(members, goredis.Z{Member: member.Member, Score: member.Score})
+			}
+			if len(members) == 0 {
+				return nil
+			}
+			return this.redis.ZAdd(item.Key, members...).Err()
+		}
+	default:
+		return fmt.Errorf("不支持的类型[%v]", item.Type)
+	}
+
+	detail := item.Type + " " + strconv.Itoa(length)
+	if item.TTL > 0 {
+		detail += fmt.Sprintf(" ttl %vs", item.TTL)
+	}
+	return this.apply(Change{Action: "RESTORE", Key: item.Key, Detail: detail}, func() error {
+		if err := this.redis.Del(item.Key).Err(); err != nil {
+			return err
+		}
+		if err := write(); err != nil {
+			return err
+		}
+		if item.TTL > 0 {
+			return this.redis.Expire(item.Key, time.Duration(item.TTL)*time.Second).Err()
+		}
+		return nil
+	})
+}
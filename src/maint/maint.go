@@ -0,0 +1,283 @@
+package maint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 轮数保存在这两个Hash里, field是PlayerId
+const (
+	EpicRoundKey   = "epic:round"
+	EnergyRoundKey = "energy:round"
+)
+
+const (
+	ROUNDS_EPIC   = "epic"
+	ROUNDS_ENERGY = "energy"
+	ROUNDS_ALL    = "all"
+)
+
+// 帮飞程序用到的所有Key
+var Patterns = []string{"epic:*", "energy:*", "quota:*", "verify:*", "maint:*", "archive:*"}
+
+func FleetTimesKey(playerId int) string {
+	return fmt.Sprintf("epic:%v:fleet:times", playerId)
+}
+
+func FleetBlacklistKey(playerId int) string {
+	return fmt.Sprintf("epic:%v:fleet:blacklist", playerId)
+}
+
+// 一次修改, DryRun的时候只记录不执行
+type Change struct {
+	Action string
+	Key    string
+	Detail string
+}
+
+func (this Change) String() string {
+	if this.Detail == "" {
+		return fmt.Sprintf("%v %v", this.Action, this.Key)
+	}
+	return fmt.Sprintf("%v %v %v", this.Action, this.Key, this.Detail)
+}
+
+// 所有的修改都通过Maint执行, 这样DryRun的时候可以列出会做哪些修改
+type Maint struct {
+	DryRun bool
+	// 迁移的时候用来判断舰队是否达到帮飞次数上限
+	MaxJoinedTimes int
+
+	redis   *goredis.Client
+	changes []Change
+}
+
+func New(redis *goredis.Client) *Maint {
+	return &Maint{redis: redis, MaxJoinedTimes: 5}
+}
+
+// 已经执行(或者DryRun的时候将要执行)的修改
+func (this *Maint) Changes() []Change {
+	return this.changes
+}
+
+func (this *Maint) apply(change Change, fn func() error) error {
+	this.changes = append(this.changes, change)
+	if this.DryRun {
+		return nil
+	}
+	return fn()
+}
+
+func (this *Maint) del(key string) error {
+	return this.apply(Change{Action: "DEL", Key: key}, func() error {
+		return this.redis.Del(key).Err()
+	})
+}
+
+func (this *Maint) hdel(key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return this.apply(Change{Action: "HDEL", Key: key, Detail: strings.Join(fields, " ")}, func() error {
+		return this.redis.HDel(key, fields...).Err()
+	})
+}
+
+func (this *Maint) hset(key string, field string, value string) error {
+	return this.apply(Change{Action: "HSET", Key: key, Detail: field + " " + value}, func() error {
+		return this.redis.HSet(key, field, value).Err()
+	})
+}
+
+func (this *Maint) set(key string, value string) error {
+	return this.apply(Change{Action: "SET", Key: key, Detail: value}, func() error {
+		return this.redis.Set(key, value).Err()
+	})
+}
+
+func (this *Maint) sadd(key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return this.apply(Change{Action: "SADD", Key: key, Detail: strings.Join(members, " ")}, func() error {
+		return this.redis.SAdd(key, members...).Err()
+	})
+}
+
+// playerIds为空表示所有账号
+func (this *Maint) ResetRounds(kind string, playerIds []int) error {
+	keys := []string{}
+	switch kind {
+	case ROUNDS_EPIC:
+		keys = append(keys, EpicRoundKey)
+	case ROUNDS_ENERGY:
+		keys = append(keys, EnergyRoundKey)
+	case "", ROUNDS_ALL:
+		keys = append(keys, EpicRoundKey, EnergyRoundKey)
+	default:
+		return fmt.Errorf("不支持的轮数类型[%v], 只支持epic, energy, all", kind)
+	}
+
+	for _, key := range keys {
+		if len(playerIds) == 0 {
+			if err := this.del(key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := this.hdel(key, playerFields(playerIds)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 删除帮飞次数少于below的舰队计数, 计数不是数字的也会删除
+func (this *Maint) PruneFleetTimes(playerIds []int, below int) error {
+	keys, err := this.playerKeys(playerIds, FleetTimesKey, "epic:*:fleet:times")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		values, err := this.redis.HGetAllMap(key).Result()
+		if err != nil {
+			return err
+		}
+		fields := []string{}
+		for fleetId, value := range values {
+			if times, err := strconv.Atoi(value); err != nil || times < below {
+				fields = append(fields, fleetId)
+			}
+		}
+		sort.Strings(fields)
+		if err := this.hdel(key, fields...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 清空舰队计数, blacklist为true的话同时清空黑名单
+func (this *Maint) ResetFleetTimes(playerIds []int, blacklist bool) error {
+	keys, err := this.playerKeys(playerIds, FleetTimesKey, "epic:*:fleet:times")
+	if err != nil {
+		return err
+	}
+	if blacklist {
+		blacklistKeys, err := this.playerKeys(playerIds, FleetBlacklistKey, "epic:*:fleet:blacklist")
+		if err != nil {
+			return err
+		}
+		keys = append(keys, blacklistKeys...)
+	}
+
+	for _, key := range keys {
+		if err := this.del(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 指定了账号的话直接生成Key, 否则列出所有账号的Key
+func (this *Maint) playerKeys(playerIds []int, key func(playerId int) string, pattern string) ([]string, error) {
+	if len(playerIds) == 0 {
+		return this.keys([]string{pattern})
+	}
+
+	keys := []string{}
+	for _, playerId := range playerIds {
+		keys = append(keys, key(playerId))
+	}
+	return keys, nil
+}
+
+type KeySize struct {
+	Key    string
+	Type   string
+	Length int64
+	TTL    time.Duration
+}
+
+// 列出匹配的Key和它们的长度(Hash/List/Set/ZSet的元素个数, String的字节数), 从大到小排列
+func (this *Maint) Sizes(patterns []string) ([]KeySize, error) {
+	keys, err := this.keys(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := []KeySize{}
+	for _, key := range keys {
+		size := KeySize{Key: key}
+		if size.Type, err = this.redis.Type(key).Result(); err != nil {
+			return nil, err
+		}
+		switch size.Type {
+		case "string":
+			size.Length, err = this.redis.StrLen(key).Result()
+		case "hash":
+			size.Length, err = this.redis.HLen(key).Result()
+		case "list":
+			size.Length, err = this.redis.LLen(key).Result()
+		case "set":
+			size.Length, err = this.redis.SCard(key).Result()
+		case "zset":
+			size.Length, err = this.redis.ZCard(key).Result()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if size.TTL, err = this.redis.TTL(key).Result(); err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, size)
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Length > sizes[j].Length
+	})
+
+	return sizes, nil
+}
+
+// 去重并且排序
+func (this *Maint) keys(patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = Patterns
+	}
+
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, pattern := range patterns {
+		matched, err := this.redis.Keys(pattern).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range matched {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+func playerFields(playerIds []int) []string {
+	fields := []string{}
+	for _, playerId := range playerIds {
+		fields = append(fields, strconv.Itoa(playerId))
+	}
+	return fields
+}
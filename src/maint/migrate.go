@@ -0,0 +1,144 @@
+package maint
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 已经执行的迁移版本, 以及每个版本执行的时间
+const (
+	VersionKey = "maint:schema:version"
+	AppliedKey = "maint:schema:applied"
+)
+
+// 迁移按Version从小到大执行, 已经发布的迁移不要修改, 需要改的话加一个新的
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(this *Maint) error
+}
+
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "删除旧版本按账号保存的轮数energy:*:round和epic:*:round, 轮数现在保存在energy:round和epic:round里",
+		Up: func(this *Maint) error {
+			keys, err := this.keys([]string{"energy:*:round", "epic:*:round"})
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := this.del(key); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "帮飞次数超过上限的舰队补充到黑名单epic:{player}:fleet:blacklist里",
+		Up: func(this *Maint) error {
+			keys, err := this.keys([]string{"epic:*:fleet:times"})
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				var playerId int
+				if _, err := fmt.Sscanf(key, "epic:%d:fleet:times", &playerId); err != nil {
+					continue
+				}
+				values, err := this.redis.HGetAllMap(key).Result()
+				if err != nil {
+					return err
+				}
+				fleetIds := []string{}
+				for fleetId, value := range values {
+					if times, err := strconv.Atoi(value); err == nil && times > this.MaxJoinedTimes {
+						fleetIds = append(fleetIds, fleetId)
+					}
+				}
+				if err := this.sadd(FleetBlacklistKey(playerId), fleetIds...); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+func LatestVersion() int {
+	latest := 0
+	for _, migration := range Migrations {
+		if migration.Version > latest {
+			latest = migration.Version
+		}
+	}
+	return latest
+}
+
+// 没有执行过迁移的话是0
+func (this *Maint) Version() (int, error) {
+	value, err := this.redis.Get(VersionKey).Result()
+	if err == goredis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%v的值[%v]有问题", VersionKey, value)
+	}
+	return version, nil
+}
+
+// 还没有执行的迁移
+func (this *Maint) Pending() ([]Migration, error) {
+	version, err := this.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, migration := range Migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// 执行到target版本为止, target为0表示执行所有的迁移, 每个迁移完成之后马上记录版本
+func (this *Maint) Migrate(target int) ([]Migration, error) {
+	if target == 0 {
+		target = LatestVersion()
+	}
+	pending, err := this.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, migration := range pending {
+		if migration.Version > target {
+			break
+		}
+		if err := migration.Up(this); err != nil {
+			return applied, fmt.Errorf("迁移[%v]失败: %v", migration.Version, err)
+		}
+		version := strconv.Itoa(migration.Version)
+		if err := this.set(VersionKey, version); err != nil {
+			return applied, err
+		}
+		if err := this.hset(AppliedKey, version, time.Now().Format(time.RFC3339)); err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
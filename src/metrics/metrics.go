@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 简单实现Prometheus的文本格式, 只支持Counter, Gauge和Histogram
+var registry = &Registry{}
+
+var labelEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+type Registry struct {
+	mu      sync.Mutex
+	metrics []collector
+}
+
+type collector interface {
+	write(buf *bytes.Buffer)
+}
+
+func (this *Registry) register(c collector) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.metrics = append(this.metrics, c)
+}
+
+func (this *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	this.mu.Lock()
+	metrics := append([]collector{}, this.metrics...)
+	this.mu.Unlock()
+
+	buf := &bytes.Buffer{}
+	for _, metric := range metrics {
+		metric.write(buf)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func Handler() http.Handler {
+	return registry
+}
+
+type vec struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	kind   string
+	labels []string
+	values map[string][]string
+}
+
+func newVec(name string, help string, kind string, labels []string) vec {
+	return vec{name: name, help: help, kind: kind, labels: labels, values: make(map[string][]string)}
+}
+
+// 调用时需要持有锁
+func (this *vec) key(labelValues []string) string {
+	if len(labelValues) != len(this.labels) {
+		panic(fmt.Sprintf("指标[%v]需要%v个标签, 实际有%v个", this.name, len(this.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	if _, ok := this.values[key]; !ok {
+		this.values[key] = append([]string{}, labelValues...)
+	}
+
+	return key
+}
+
+func (this *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(this.values))
+	for key := range this.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (this *vec) writeHeader(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "# HELP %v %v\n", this.name, this.help)
+	fmt.Fprintf(buf, "# TYPE %v %v\n", this.name, this.kind)
+}
+
+func (this *vec) labelString(key string, extra ...string) string {
+	pairs := []string{}
+	for i, value := range this.values[key] {
+		pairs = append(pairs, fmt.Sprintf("%v=\"%v\"", this.labels[i], labelEscaper.Replace(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%v=\"%v\"", extra[i], labelEscaper.Replace(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type CounterVec struct {
+	vec
+	counts map[string]float64
+}
+
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{vec: newVec(name, help, "counter", labels), counts: make(map[string]float64)}
+	registry.register(counter)
+	return counter
+}
+
+func (this *CounterVec) Inc(labelValues ...string) {
+	this.Add(1, labelValues...)
+}
+
+func (this *CounterVec) Add(value float64, labelValues ...string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.counts[this.key(labelValues)] += value
+}
+
+func (this *CounterVec) write(buf *bytes.Buffer) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.writeHeader(buf)
+	for _, key := range this.sortedKeys() {
+		fmt.Fprintf(buf, "%v%v %v\n", this.name, this.labelString(key), formatFloat(this.counts[key]))
+	}
+}
+
+type GaugeVec struct {
+	vec
+	gauges map[string]float64
+}
+
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	gauge := &GaugeVec{vec: newVec(name, help, "gauge", labels), gauges: make(map[string]float64)}
+	registry.register(gauge)
+	return gauge
+}
+
+func (this *GaugeVec) Set(value float64, labelValues ...string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.gauges[this.key(labelValues)] = value
+}
+
+func (this *GaugeVec) write(buf *bytes.Buffer) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.writeHeader(buf)
+	for _, key := range this.sortedKeys() {
+		fmt.Fprintf(buf, "%v%v %v\n", this.name, this.labelString(key), formatFloat(this.gauges[key]))
+	}
+}
+
+type HistogramVec struct {
+	vec
+	buckets []float64
+	counts  map[string][]uint64
+	sums    map[string]float64
+	totals  map[string]uint64
+}
+
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	histogram := &HistogramVec{
+		vec:     newVec(name, help, "histogram", labels),
+		buckets: buckets,
+		counts:  make(map[string][]uint64),
+		sums:    make(map[string]float64),
+		totals:  make(map[string]uint64),
+	}
+	registry.register(histogram)
+	return histogram
+}
+
+func (this *HistogramVec) Observe(value float64, labelValues ...string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	key := this.key(labelValues)
+	if _, ok := this.counts[key]; !ok {
+		this.counts[key] = make([]uint64, len(this.buckets))
+	}
+	for i, bound := range this.buckets {
+		if value <= bound {
+			this.counts[key][i] += 1
+		}
+	}
+	this.sums[key] += value
+	this.totals[key] += 1
+}
+
+func (this *HistogramVec) write(buf *bytes.Buffer) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.writeHeader(buf)
+	for _, key := range this.sortedKeys() {
+		for i, bound := range this.buckets {
+			fmt.Fprintf(buf, "%v_bucket%v %v\n", this.name, this.labelString(key, "le", formatFloat(bound)), this.counts[key][i])
+		}
+		fmt.Fprintf(buf, "%v_bucket%v %v\n", this.name, this.labelString(key, "le", "+Inf"), this.totals[key])
+		fmt.Fprintf(buf, "%v_sum%v %v\n", this.name, this.labelString(key), formatFloat(this.sums[key]))
+		fmt.Fprintf(buf, "%v_count%v %v\n", this.name, this.labelString(key), this.totals[key])
+	}
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	STATE_IDLE     = "idle"
+	STATE_IN_FLEET = "in_fleet"
+	STATE_PAUSED   = "paused"
+	// AuthToken或者Cookie失效, 等待更新配置文件
+	STATE_SUSPENDED = "suspended"
+)
+
+var States = []string{STATE_IDLE, STATE_IN_FLEET, STATE_PAUSED, STATE_SUSPENDED}
+
+var (
+	Rounds             = NewCounterVec("walkr_rounds_total", "查看邀请的轮数", "account")
+	FleetsJoined       = NewCounterVec("walkr_fleets_joined_total", "加入舰队的次数", "account")
+	FleetsLeft         = NewCounterVec("walkr_fleets_left_total", "成功离开舰队的次数", "account")
+	LeaveRetries       = NewCounterVec("walkr_leave_retries_total", "离开舰队失败后重试的次数", "account")
+	LeaveFailures      = NewCounterVec("walkr_leave_failures_total", "重试之后仍然没有离开舰队的次数", "account")
+	FriendConfirmation = NewCounterVec("walkr_friend_confirmations_total", "通过好友申请的次数", "account", "result")
+	CommentsPosted     = NewCounterVec("walkr_comments_posted_total", "留言的次数", "account")
+	AccountState       = NewGaugeVec("walkr_account_state", "账号当前的状态, 当前状态为1", "account", "state")
+
+	APIRequests = NewCounterVec("walkr_api_requests_total", "请求Walkr接口的次数", "endpoint", "status")
+	APILatency  = NewHistogramVec("walkr_api_request_duration_seconds", "请求Walkr接口的耗时",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "endpoint")
+)
+
+var idRegexp = regexp.MustCompile(`/\d+(/|$)`)
+
+func SetAccountState(account string, state string) {
+	for _, s := range States {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		AccountState.Set(value, account, s)
+	}
+}
+
+// 记录每个接口的耗时和返回状态, 路径里的ID会被替换掉, 防止标签太多
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (this *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := this.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	endpoint := Endpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	APILatency.Observe(time.Since(start).Seconds(), endpoint)
+	if err != nil {
+		APIRequests.Inc(endpoint, "error")
+	} else {
+		APIRequests.Inc(endpoint, fmt.Sprintf("%vxx", resp.StatusCode/100))
+	}
+
+	return resp, err
+}
+
+func Endpoint(path string) string {
+	for idRegexp.MatchString(path) {
+		path = idRegexp.ReplaceAllString(path, "/:id$1")
+	}
+	return path
+}
+
+func Listen(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return http.ListenAndServe(addr, mux)
+}
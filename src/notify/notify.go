@@ -0,0 +1,343 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"logger"
+	"net/http"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var log = logger.New("notify", logger.Fields{})
+
+// 需要人来处理的事件
+const (
+	EVENT_LEAVE_FAILED      = "leave_failed"
+	EVENT_AUTH_REJECTED     = "auth_rejected"
+	EVENT_FLEET_BLACKLISTED = "fleet_blacklisted"
+	EVENT_WORKER_CRASHED    = "worker_crashed"
+	EVENT_QUOTA_EXHAUSTED   = "quota_exhausted"
+	EVENT_ACCOUNT_SUSPENDED = "account_suspended"
+)
+
+// 账号配置文件里的[Webhook]
+type Config struct {
+	URL string
+	// 可选, 用text/template生成请求内容, 默认是Notification的JSON
+	// 模板不会转义, 字符串要用json函数生成带引号的JSON字符串, 例如
+	// '{"msgtype": "text", "text": {"content": {{printf "「%s」%s" .Account .Message | json}}}}'
+	Template string
+	// 没有发送成功的通知保存在这里, 重启之后继续发送
+	Outbox string
+	// 只发送这些事件, 为空则全部发送
+	Events []string
+	// 两次重试之间最长等待多久, 默认10分钟
+	MaxBackoff string
+}
+
+type Notification struct {
+	Event    string    `json:"event"`
+	Account  string    `json:"account"`
+	PlayerId int       `json:"player_id"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// 最多尝试发送多少次, 发件箱最多保存多少条通知, 超过的话放弃最早的
+var MaxAttempts = 20
+var MaxOutbox = 1000
+
+// Id在发件箱里唯一, 用来找到发送完的那一条; 同一时间两条一样的通知也能分开
+type entry struct {
+	Id           int64        `json:"id"`
+	Notification Notification `json:"notification"`
+	Attempts     int          `json:"attempts"`
+	NextAttempt  time.Time    `json:"next_attempt"`
+	// 最后一次发送失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+// 重试也不会成功的错误, 比如模板有问题或者Webhook返回4xx
+type permanentError struct {
+	error
+}
+
+// 发送之前先写到本地的发件箱, 发送成功之后才删除, 失败的话指数退避重试
+type Notifier struct {
+	url        string
+	template   *template.Template
+	outbox     string
+	events     map[string]bool
+	maxBackoff time.Duration
+	client     *http.Client
+
+	mu      sync.Mutex
+	entries []entry
+	lastId  int64
+	wakeup  chan struct{}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+}
+
+func New(config Config) (*Notifier, error) {
+	notifier := &Notifier{
+		url:        config.URL,
+		outbox:     config.Outbox,
+		events:     make(map[string]bool),
+		maxBackoff: 10 * time.Minute,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		wakeup:     make(chan struct{}, 1),
+	}
+	if notifier.outbox == "" {
+		notifier.outbox = "outbox.jsonl"
+	}
+	if config.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("通知模板有问题: %v", err)
+		}
+		notifier.template = tmpl
+	}
+	if config.MaxBackoff != "" {
+		maxBackoff, err := time.ParseDuration(config.MaxBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("最长重试间隔[%v]有问题: %v", config.MaxBackoff, err)
+		}
+		notifier.maxBackoff = maxBackoff
+	}
+	for _, event := range config.Events {
+		notifier.events[event] = true
+	}
+
+	if err := notifier.load(); err != nil {
+		return nil, fmt.Errorf("读取发件箱[%v]失败: %v", notifier.outbox, err)
+	}
+	go notifier.deliver()
+
+	return notifier, nil
+}
+
+// 没有配置Webhook的时候notifier是nil, 直接忽略
+func (this *Notifier) Send(event string, account string, playerId int, format string, args ...interface{}) {
+	if this == nil || this.url == "" {
+		return
+	}
+	if len(this.events) > 0 && !this.events[event] {
+		return
+	}
+
+	notification := Notification{
+		Event:    event,
+		Account:  account,
+		PlayerId: playerId,
+		Message:  logger.Redact(fmt.Sprintf(format, args...)),
+		Time:     time.Now(),
+	}
+
+	this.mu.Lock()
+	for len(this.entries) >= MaxOutbox {
+		this.bury(this.entries[0], "发件箱已满")
+		this.entries = this.entries[1:]
+	}
+	this.lastId += 1
+	this.entries = append(this.entries, entry{Id: this.lastId, Notification: notification, NextAttempt: notification.Time})
+	err := this.save()
+	this.mu.Unlock()
+	if err != nil {
+		log.Error("保存通知到发件箱失败: %v", err)
+	}
+
+	select {
+	case this.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (this *Notifier) deliver() {
+	for {
+		wait := time.Minute
+
+		this.mu.Lock()
+		now := time.Now()
+		due := -1
+		for i, e := range this.entries {
+			if !e.NextAttempt.After(now) {
+				due = i
+				break
+			}
+			if d := e.NextAttempt.Sub(now); d < wait {
+				wait = d
+			}
+		}
+		var current entry
+		if due >= 0 {
+			current = this.entries[due]
+		}
+		this.mu.Unlock()
+
+		if due < 0 {
+			select {
+			case <-time.After(wait):
+			case <-this.wakeup:
+			}
+			continue
+		}
+
+		err := this.post(current.Notification)
+
+		this.mu.Lock()
+		for i, e := range this.entries {
+			if e.Id != current.Id {
+				continue
+			}
+			if err == nil {
+				this.entries = append(this.entries[:i], this.entries[i+1:]...)
+				break
+			}
+
+			e.Attempts += 1
+			e.Error = err.Error()
+			if _, ok := err.(permanentError); ok || e.Attempts >= MaxAttempts {
+				log.Error("发送通知[%v]失败%v次, 不再重试: %v", e.Notification.Event, e.Attempts, err)
+				this.bury(e, err.Error())
+				this.entries = append(this.entries[:i], this.entries[i+1:]...)
+				break
+			}
+			e.NextAttempt = time.Now().Add(this.backoff(e.Attempts))
+			this.entries[i] = e
+			log.Warning("发送通知[%v]失败, 第%v次重试将在%v: %v", e.Notification.Event, e.Attempts,
+				e.NextAttempt.Format("15:04:05"), err)
+			break
+		}
+		if err := this.save(); err != nil {
+			log.Error("保存发件箱失败: %v", err)
+		}
+		this.mu.Unlock()
+	}
+}
+
+func (this *Notifier) post(notification Notification) error {
+	var body []byte
+	if this.template != nil {
+		buf := &bytes.Buffer{}
+		if err := this.template.Execute(buf, notification); err != nil {
+			return permanentError{fmt.Errorf("生成通知内容失败: %v", err)}
+		}
+		body = buf.Bytes()
+	} else {
+		b, err := json.Marshal(notification)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+
+	resp, err := this.client.Post(this.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{fmt.Errorf("Webhook返回%v", resp.Status)}
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Webhook返回%v", resp.Status)
+	}
+
+	return nil
+}
+
+// 5秒开始每次翻倍, 最长不超过maxBackoff
+func (this *Notifier) backoff(attempts int) time.Duration {
+	backoff := 5 * time.Second
+	for i := 1; i < attempts && backoff < this.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > this.maxBackoff {
+		backoff = this.maxBackoff
+	}
+
+	return backoff
+}
+
+func (this *Notifier) load() error {
+	file, err := os.Open(this.outbox)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Error("发件箱里有无法解析的通知: %v", err)
+			continue
+		}
+		this.entries = append(this.entries, e)
+		if e.Id > this.lastId {
+			this.lastId = e.Id
+		}
+	}
+	// 以前的发件箱没有Id
+	for i := range this.entries {
+		if this.entries[i].Id == 0 {
+			this.lastId += 1
+			this.entries[i].Id = this.lastId
+		}
+	}
+	if len(this.entries) > 0 {
+		log.Notice("发件箱里有%v条没有发送的通知", len(this.entries))
+	}
+
+	return scanner.Err()
+}
+
+// 放弃的通知追加到发件箱旁边的.dead文件里, 调用时需要持有锁
+func (this *Notifier) bury(e entry, reason string) {
+	e.Error = reason
+	b, err := json.Marshal(e)
+	if err == nil {
+		var file *os.File
+		if file, err = os.OpenFile(this.outbox+".dead", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err == nil {
+			_, err = file.Write(append(b, '\n'))
+			file.Close()
+		}
+	}
+	if err != nil {
+		log.Error("保存放弃的通知[%v]失败: %v", e.Notification.Event, err)
+	}
+}
+
+// 调用时需要持有锁, 先写临时文件再替换, 防止写到一半程序退出
+func (this *Notifier) save() error {
+	buf := &bytes.Buffer{}
+	for _, e := range this.entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	tmp := this.outbox + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, this.outbox)
+}
@@ -0,0 +1,189 @@
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 小于等于0表示不限制
+type Limits struct {
+	FleetsPerHour      int
+	FleetsPerDay       int
+	FleetMinutesPerDay int
+}
+
+// 账号的帮飞配额, 计数保存在Redis里, 每天在ResetAt的时候重置
+type Tracker struct {
+	mu     sync.Mutex
+	limits Limits
+
+	counter  counter
+	playerId int
+	resetAt  time.Duration
+	location *time.Location
+}
+
+type Remaining struct {
+	Limits         Limits
+	FleetsThisHour int
+	FleetsToday    int
+	SecondsToday   int
+}
+
+func NewTracker(redis *goredis.Client, playerId int, limits Limits, resetAt string, location *time.Location) (*Tracker, error) {
+	tracker := &Tracker{limits: limits, counter: redisCounter{redis}, playerId: playerId, location: location}
+	if resetAt != "" {
+		parts := strings.Split(resetAt, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("配额重置时间[%v]格式应该是HH:MM", resetAt)
+		}
+		hour, err1 := strconv.Atoi(parts[0])
+		minute, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+			return nil, fmt.Errorf("配额重置时间[%v]有问题", resetAt)
+		}
+		tracker.resetAt = time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	}
+
+	return tracker, nil
+}
+
+func (this *Tracker) Limits() Limits {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.limits
+}
+
+// 运行时调整配额
+func (this *Tracker) SetLimits(limits Limits) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.limits = limits
+}
+
+func (this *Tracker) Remaining(now time.Time) Remaining {
+	limits := this.Limits()
+	day := this.counter.counts(this.dayKey(now))
+	fleetsToday, secondsToday := day["fleets"], day["seconds"]
+	fleetsThisHour := this.counter.counts(this.hourKey(now))["fleets"]
+
+	return Remaining{
+		Limits:         limits,
+		FleetsThisHour: remaining(limits.FleetsPerHour, fleetsThisHour),
+		FleetsToday:    remaining(limits.FleetsPerDay, fleetsToday),
+		SecondsToday:   remaining(limits.FleetMinutesPerDay*60, secondsToday),
+	}
+}
+
+func (this *Tracker) RecordJoin(now time.Time) {
+	this.counter.incr(this.dayKey(now), "fleets", 1, 48*time.Hour)
+	this.counter.incr(this.hourKey(now), "fleets", 1, 2*time.Hour)
+}
+
+// 在舰队里停留的时间记在加入舰队的那一天
+func (this *Tracker) RecordDuration(joinedAt time.Time, duration time.Duration) {
+	this.counter.incr(this.dayKey(joinedAt), "seconds", int64(duration/time.Second), 48*time.Hour)
+}
+
+// 配额用完之后, 下一次可以帮飞的时间
+func (this *Tracker) ResumeAt(now time.Time, wait time.Duration) time.Time {
+	remaining := this.Remaining(now)
+	if !remaining.dailyAllows(wait) {
+		return this.periodStart(now).AddDate(0, 0, 1)
+	}
+	if remaining.FleetsThisHour == 0 {
+		return now.In(this.location).Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return now
+}
+
+// 配额是否还够再帮飞一次, wait是预计在舰队里停留的时间
+func (this Remaining) Allows(wait time.Duration) bool {
+	return this.FleetsThisHour != 0 && this.dailyAllows(wait)
+}
+
+func (this Remaining) dailyAllows(wait time.Duration) bool {
+	if this.FleetsToday == 0 {
+		return false
+	}
+	return this.SecondsToday < 0 || time.Duration(this.SecondsToday)*time.Second >= wait
+}
+
+func (this Remaining) String() string {
+	return fmt.Sprintf("本小时剩余%v次, 今天剩余%v次, 今天剩余%v分钟",
+		format(this.FleetsThisHour), format(this.FleetsToday), format(minutes(this.SecondsToday)))
+}
+
+// 今天的配额从什么时候开始算, 还没到重置时间的话算前一天的
+func (this *Tracker) periodStart(now time.Time) time.Time {
+	local := now.In(this.location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, this.location).Add(this.resetAt)
+	if local.Before(start) {
+		start = start.AddDate(0, 0, -1)
+	}
+
+	return start
+}
+
+func (this *Tracker) dayKey(now time.Time) string {
+	return fmt.Sprintf("quota:%v:%v", this.playerId, this.periodStart(now).Format("20060102"))
+}
+
+func (this *Tracker) hourKey(now time.Time) string {
+	return fmt.Sprintf("quota:%v:%v", this.playerId, now.In(this.location).Format("2006010215"))
+}
+
+// 配额的计数, 每个Key是一个Hash, 过了ttl自动删除; 测试的时候换成内存里的
+type counter interface {
+	counts(key string) map[string]int
+	incr(key string, field string, n int64, ttl time.Duration)
+}
+
+type redisCounter struct {
+	redis *goredis.Client
+}
+
+func (this redisCounter) counts(key string) map[string]int {
+	counts := make(map[string]int)
+	for field, value := range this.redis.HGetAllMap(key).Val() {
+		counts[field], _ = strconv.Atoi(value)
+	}
+	return counts
+}
+
+func (this redisCounter) incr(key string, field string, n int64, ttl time.Duration) {
+	this.redis.HIncrBy(key, field, n)
+	this.redis.Expire(key, ttl)
+}
+
+// 不限制的时候返回-1
+func remaining(limit int, used int) int {
+	if limit <= 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+
+	return limit - used
+}
+
+func minutes(seconds int) int {
+	if seconds < 0 {
+		return -1
+	}
+	return seconds / 60
+}
+
+func format(value int) string {
+	if value < 0 {
+		return "不限"
+	}
+	return strconv.Itoa(value)
+}
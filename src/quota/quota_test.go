@@ -0,0 +1,118 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+// 内存里的计数, 不管过期时间, Key里已经带了日期和小时
+type memoryCounter map[string]map[string]int
+
+func (this memoryCounter) counts(key string) map[string]int {
+	counts := make(map[string]int)
+	for field, value := range this[key] {
+		counts[field] = value
+	}
+	return counts
+}
+
+func (this memoryCounter) incr(key string, field string, n int64, ttl time.Duration) {
+	if this[key] == nil {
+		this[key] = make(map[string]int)
+	}
+	this[key][field] += int(n)
+}
+
+func newTestTracker(t *testing.T, limits Limits, resetAt string) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker(nil, 1, limits, resetAt, shanghai)
+	if err != nil {
+		t.Fatalf("创建配额失败: %v", err)
+	}
+	tracker.counter = memoryCounter{}
+	return tracker
+}
+
+var shanghai = time.FixedZone("CST", 8*60*60)
+
+func at(day int, hour int, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, shanghai)
+}
+
+func TestLimits(t *testing.T) {
+	wait := 5 * time.Minute
+	for _, item := range []struct {
+		name    string
+		limits  Limits
+		resetAt string
+		// 依次加入舰队的时间, 每次停留wait
+		joins    []time.Time
+		now      time.Time
+		allows   bool
+		resumeAt time.Time
+	}{
+		{"不限制", Limits{}, "", []time.Time{at(2, 10, 0), at(2, 10, 10)}, at(2, 10, 20), true, at(2, 10, 20)},
+		{"每小时没用完", Limits{FleetsPerHour: 2}, "", []time.Time{at(2, 10, 0)}, at(2, 10, 20), true, at(2, 10, 20)},
+		{"每小时用完", Limits{FleetsPerHour: 2}, "", []time.Time{at(2, 10, 0), at(2, 10, 10)}, at(2, 10, 20), false, at(2, 11, 0)},
+		{"下一个小时", Limits{FleetsPerHour: 2}, "", []time.Time{at(2, 10, 0), at(2, 10, 10)}, at(2, 11, 0), true, at(2, 11, 0)},
+		{"每天用完", Limits{FleetsPerDay: 2}, "", []time.Time{at(2, 10, 0), at(2, 20, 0)}, at(2, 23, 59), false, at(3, 0, 0)},
+		{"第二天", Limits{FleetsPerDay: 2}, "", []time.Time{at(2, 10, 0), at(2, 20, 0)}, at(3, 0, 0), true, at(3, 0, 0)},
+		// 04:00重置的话, 凌晨还算前一天的配额
+		{"重置之前", Limits{FleetsPerDay: 2}, "04:00", []time.Time{at(2, 10, 0), at(3, 3, 0)}, at(3, 3, 59), false, at(3, 4, 0)},
+		{"重置之后", Limits{FleetsPerDay: 2}, "04:00", []time.Time{at(2, 10, 0), at(3, 3, 0)}, at(3, 4, 0), true, at(3, 4, 0)},
+		{"每天和每小时都用完", Limits{FleetsPerHour: 1, FleetsPerDay: 1}, "04:00", []time.Time{at(2, 10, 0)}, at(2, 10, 30), false, at(3, 4, 0)},
+		// 每天14分钟, 用了10分钟还剩4分钟, 不够再待5分钟
+		{"分钟用完", Limits{FleetMinutesPerDay: 14}, "", []time.Time{at(2, 10, 0), at(2, 11, 0)}, at(2, 12, 0), false, at(3, 0, 0)},
+		{"分钟刚好", Limits{FleetMinutesPerDay: 15}, "", []time.Time{at(2, 10, 0), at(2, 11, 0)}, at(2, 12, 0), true, at(2, 12, 0)},
+		// 停留时间记在加入的那一天, 跨过午夜也一样
+		{"跨过午夜", Limits{FleetMinutesPerDay: 5}, "", []time.Time{at(2, 23, 58)}, at(3, 0, 10), true, at(3, 0, 10)},
+	} {
+		tracker := newTestTracker(t, item.limits, item.resetAt)
+		for _, joinedAt := range item.joins {
+			tracker.RecordJoin(joinedAt)
+			tracker.RecordDuration(joinedAt, wait)
+		}
+
+		remaining := tracker.Remaining(item.now)
+		if got := remaining.Allows(wait); got != item.allows {
+			t.Errorf("[%v]应该是%v, 结果是%v(%v)", item.name, item.allows, got, remaining)
+		}
+		if got := tracker.ResumeAt(item.now, wait); !got.Equal(item.resumeAt) {
+			t.Errorf("[%v]恢复时间应该是%v, 结果是%v", item.name, item.resumeAt, got)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	tracker := newTestTracker(t, Limits{FleetsPerHour: 3, FleetsPerDay: 10, FleetMinutesPerDay: 60}, "")
+	tracker.RecordJoin(at(2, 10, 0))
+	tracker.RecordDuration(at(2, 10, 0), 90*time.Second)
+	tracker.RecordJoin(at(2, 9, 59))
+	tracker.RecordDuration(at(2, 9, 59), 5*time.Minute)
+
+	remaining := tracker.Remaining(at(2, 10, 30))
+	if remaining.FleetsThisHour != 2 || remaining.FleetsToday != 8 || remaining.SecondsToday != 3600-390 {
+		t.Errorf("剩余配额有问题: %+v", remaining)
+	}
+	if want := "本小时剩余2次, 今天剩余8次, 今天剩余53分钟"; remaining.String() != want {
+		t.Errorf("应该是%v, 结果是%v", want, remaining)
+	}
+
+	// 运行时调整配额马上生效
+	tracker.SetLimits(Limits{FleetsPerHour: 1})
+	remaining = tracker.Remaining(at(2, 10, 30))
+	if remaining.FleetsThisHour != 0 || remaining.FleetsToday != -1 || remaining.SecondsToday != -1 {
+		t.Errorf("调整之后的剩余配额有问题: %+v", remaining)
+	}
+	if want := "本小时剩余0次, 今天剩余不限次, 今天剩余不限分钟"; remaining.String() != want {
+		t.Errorf("应该是%v, 结果是%v", want, remaining)
+	}
+}
+
+func TestResetAt(t *testing.T) {
+	for _, resetAt := range []string{"4", "24:00", "04:60", "aa:bb", "-1:00"} {
+		if _, err := NewTracker(nil, 1, Limits{}, resetAt, shanghai); err == nil {
+			t.Errorf("配额重置时间[%v]应该报错", resetAt)
+		}
+	}
+}
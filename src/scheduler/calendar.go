@@ -0,0 +1,262 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 找下一个活跃时间时最多往后看多久
+var MaxLookahead = 31 * 24 * time.Hour
+
+var dailyWindowRegexp = regexp.MustCompile(`^(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})(?:\s+(\S+))?$`)
+
+// 账号的活跃时间, 由若干个时间窗口和不工作的日期组成
+// 时间窗口可以是 "08:00-23:30 Asia/Shanghai" 或者Cron表达式 "* 8-22 * * 1-5"
+// Cron表达式命中的每一分钟都算活跃, 可以用 "CRON_TZ=Asia/Shanghai " 前缀指定时区
+type Calendar struct {
+	windows   []window
+	blackouts map[string]bool
+	location  *time.Location
+}
+
+type window interface {
+	contains(t time.Time) bool
+}
+
+func NewCalendar(windows []string, blackoutDates []string, timeZone string) (*Calendar, error) {
+	location := time.Local
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("时区[%v]有问题: %v", timeZone, err)
+		}
+		location = loc
+	}
+
+	calendar := &Calendar{blackouts: make(map[string]bool), location: location}
+	for _, expr := range windows {
+		w, err := parseWindow(strings.TrimSpace(expr), location)
+		if err != nil {
+			return nil, fmt.Errorf("活跃时间[%v]有问题: %v", expr, err)
+		}
+		calendar.windows = append(calendar.windows, w)
+	}
+	for _, date := range blackoutDates {
+		day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(date), location)
+		if err != nil {
+			return nil, fmt.Errorf("休息日期[%v]有问题: %v", date, err)
+		}
+		calendar.blackouts[day.Format("2006-01-02")] = true
+	}
+
+	return calendar, nil
+}
+
+// 没有配置时间窗口的话, 除了休息日期之外都是活跃的
+func (this *Calendar) Active(t time.Time) bool {
+	if this == nil {
+		return true
+	}
+	if this.blackouts[t.In(this.location).Format("2006-01-02")] {
+		return false
+	}
+	if len(this.windows) == 0 {
+		return true
+	}
+	for _, w := range this.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *Calendar) Location() *time.Location {
+	return this.location
+}
+
+// 下一个活跃的时间点, 精确到分钟
+func (this *Calendar) NextActive(t time.Time) time.Time {
+	if this.Active(t) {
+		return t
+	}
+
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for deadline := t.Add(MaxLookahead); next.Before(deadline); next = next.Add(time.Minute) {
+		if this.Active(next) {
+			return next
+		}
+	}
+
+	return next
+}
+
+// 每天固定的时间段, 结束时间比开始时间早表示跨过午夜
+type dailyWindow struct {
+	start    int
+	end      int
+	location *time.Location
+}
+
+func (this dailyWindow) contains(t time.Time) bool {
+	t = t.In(this.location)
+	minute := t.Hour()*60 + t.Minute()
+	if this.start <= this.end {
+		return minute >= this.start && minute < this.end
+	}
+
+	return minute >= this.start || minute < this.end
+}
+
+type cronWindow struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	anyDay   bool
+	anyWeek  bool
+	location *time.Location
+}
+
+func (this cronWindow) contains(t time.Time) bool {
+	t = t.In(this.location)
+	if !hasBit(this.minutes, t.Minute()) || !hasBit(this.hours, t.Hour()) || !hasBit(this.months, int(t.Month())) {
+		return false
+	}
+
+	// 和Cron一样, 日期和星期都有限制的时候满足一个就可以
+	dayOk := hasBit(this.days, t.Day())
+	weekOk := hasBit(this.weekdays, int(t.Weekday()))
+	if this.anyDay || this.anyWeek {
+		return dayOk && weekOk
+	}
+	return dayOk || weekOk
+}
+
+func parseWindow(expr string, location *time.Location) (window, error) {
+	if matches := dailyWindowRegexp.FindStringSubmatch(expr); matches != nil {
+		if matches[5] != "" {
+			loc, err := time.LoadLocation(matches[5])
+			if err != nil {
+				return nil, err
+			}
+			location = loc
+		}
+		start, err := minuteOfDay(matches[1], matches[2])
+		if err != nil {
+			return nil, err
+		}
+		end, err := minuteOfDay(matches[3], matches[4])
+		if err != nil {
+			return nil, err
+		}
+
+		return dailyWindow{start: start, end: end, location: location}, nil
+	}
+
+	if strings.HasPrefix(expr, "CRON_TZ=") {
+		parts := strings.SplitN(expr, " ", 2)
+		loc, err := time.LoadLocation(strings.TrimPrefix(parts[0], "CRON_TZ="))
+		if err != nil {
+			return nil, err
+		}
+		location = loc
+		if len(parts) < 2 {
+			return nil, errors.New("缺少Cron表达式")
+		}
+		expr = parts[1]
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("Cron表达式需要5个字段: 分 时 日 月 星期")
+	}
+
+	var err error
+	cron := cronWindow{location: location}
+	if cron.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if cron.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if cron.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if cron.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if cron.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// 7和0都表示周日
+	if hasBit(cron.weekdays, 7) {
+		cron.weekdays |= 1
+	}
+	cron.anyDay = strings.HasPrefix(fields[2], "*")
+	cron.anyWeek = strings.HasPrefix(fields[4], "*")
+
+	return cron, nil
+}
+
+// 支持 * a a-b */n a-b/n 以及用逗号分隔的组合
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if index := strings.Index(part, "/"); index >= 0 {
+			s, err := strconv.Atoi(part[index+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("步长[%v]有问题", part)
+			}
+			step = s
+			part = part[:index]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			s, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("字段[%v]有问题", field)
+			}
+			start, end = s, s
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("字段[%v]有问题", field)
+				}
+			} else if step > 1 {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("字段[%v]超出范围%v-%v", field, min, max)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+func minuteOfDay(hour string, minute string) (int, error) {
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	if h > 24 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("时间[%v:%v]有问题", hour, minute)
+	}
+
+	return h*60 + m, nil
+}
+
+func hasBit(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustCalendar(t *testing.T, windows []string, blackoutDates []string, timeZone string) *Calendar {
+	t.Helper()
+	calendar, err := NewCalendar(windows, blackoutDates, timeZone)
+	if err != nil {
+		t.Fatalf("创建活跃时间失败: %v", err)
+	}
+	return calendar
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("没有时区数据[%v]: %v", name, err)
+	}
+	return location
+}
+
+func TestDailyWindow(t *testing.T) {
+	for _, item := range []struct {
+		window string
+		at     string
+		active bool
+	}{
+		{"08:00-23:30", "2026-03-02 07:59", false},
+		{"08:00-23:30", "2026-03-02 08:00", true},
+		{"08:00-23:30", "2026-03-02 23:29", true},
+		{"08:00-23:30", "2026-03-02 23:30", false},
+		// 跨过午夜
+		{"22:00-06:00", "2026-03-02 21:59", false},
+		{"22:00-06:00", "2026-03-02 22:00", true},
+		{"22:00-06:00", "2026-03-02 23:59", true},
+		{"22:00-06:00", "2026-03-03 00:00", true},
+		{"22:00-06:00", "2026-03-03 05:59", true},
+		{"22:00-06:00", "2026-03-03 06:00", false},
+		{"00:00-24:00", "2026-03-03 23:59", true},
+	} {
+		calendar := mustCalendar(t, []string{item.window}, nil, "UTC")
+		at, _ := time.ParseInLocation("2006-01-02 15:04", item.at, time.UTC)
+		if got := calendar.Active(at); got != item.active {
+			t.Errorf("[%v]在%v应该是%v, 结果是%v", item.window, item.at, item.active, got)
+		}
+	}
+}
+
+// 和vixie cron一样, 日期和星期都不是*的时候满足一个就可以, 有一个是*的话两个都要满足
+func TestCronDayOrWeekday(t *testing.T) {
+	// 2026-03-13是周五, 2026-03-06是周五, 2026-04-13是周一
+	for _, item := range []struct {
+		window string
+		at     string
+		active bool
+	}{
+		{"0 9 13 * 5", "2026-03-13 09:00", true},
+		{"0 9 13 * 5", "2026-03-06 09:00", true},
+		{"0 9 13 * 5", "2026-04-13 09:00", true},
+		{"0 9 13 * 5", "2026-03-12 09:00", false},
+		{"0 9 13 * 5", "2026-03-13 09:01", false},
+		{"0 9 * * 5", "2026-03-06 09:00", true},
+		{"0 9 * * 5", "2026-04-13 09:00", false},
+		{"0 9 13 * *", "2026-04-13 09:00", true},
+		{"0 9 13 * *", "2026-03-06 09:00", false},
+		// */2开头是*, 所以两个都要满足
+		{"0 9 */2 * 5", "2026-03-13 09:00", true},
+		{"0 9 */2 * 5", "2026-03-06 09:00", false},
+		// 7和0都是周日, 2026-03-08是周日
+		{"0 9 * * 7", "2026-03-08 09:00", true},
+	} {
+		calendar := mustCalendar(t, []string{item.window}, nil, "UTC")
+		at, _ := time.ParseInLocation("2006-01-02 15:04", item.at, time.UTC)
+		if got := calendar.Active(at); got != item.active {
+			t.Errorf("[%v]在%v应该是%v, 结果是%v", item.window, item.at, item.active, got)
+		}
+	}
+}
+
+func TestBlackoutDates(t *testing.T) {
+	shanghai := mustLocation(t, "Asia/Shanghai")
+	calendar := mustCalendar(t, []string{"08:00-23:00"}, []string{"2026-03-03"}, "Asia/Shanghai")
+	for _, item := range []struct {
+		at     time.Time
+		active bool
+	}{
+		{time.Date(2026, 3, 2, 12, 0, 0, 0, shanghai), true},
+		{time.Date(2026, 3, 3, 12, 0, 0, 0, shanghai), false},
+		{time.Date(2026, 3, 4, 12, 0, 0, 0, shanghai), true},
+		// 休息日期按账号的时区算, UTC的3月2日晚上已经是上海的3月3日了
+		{time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC), false},
+	} {
+		if got := calendar.Active(item.at); got != item.active {
+			t.Errorf("%v应该是%v, 结果是%v", item.at, item.active, got)
+		}
+	}
+
+	// 没有时间窗口的话除了休息日期都是活跃的
+	calendar = mustCalendar(t, nil, []string{"2026-03-03"}, "Asia/Shanghai")
+	next := calendar.NextActive(time.Date(2026, 3, 3, 12, 0, 0, 0, shanghai))
+	if want := time.Date(2026, 3, 4, 0, 0, 0, 0, shanghai); !next.Equal(want) {
+		t.Errorf("下一个活跃时间应该是%v, 结果是%v", want, next)
+	}
+
+	if _, err := NewCalendar(nil, []string{"2026-13-01"}, "UTC"); err == nil {
+		t.Errorf("休息日期有问题的时候应该报错")
+	}
+}
+
+func TestNextActiveDST(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	for _, item := range []struct {
+		name   string
+		window string
+		from   time.Time
+		want   time.Time
+	}{
+		// 2026-03-08 02:00夏令时开始, 直接跳到03:00, 02:30不存在
+		{"夏令时开始", "02:30-03:30", time.Date(2026, 3, 8, 1, 0, 0, 0, newYork), time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC)},
+		{"夏令时开始之后", "08:00-09:00", time.Date(2026, 3, 8, 1, 0, 0, 0, newYork), time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		// 2026-11-01 02:00夏令时结束, 01:00-02:00过两遍, 第一次就算
+		{"夏令时结束", "01:30-02:00", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC)},
+		{"夏令时结束之后", "08:00-09:00", time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), time.Date(2026, 11, 1, 13, 0, 0, 0, time.UTC)},
+		{"Cron", "CRON_TZ=America/New_York 0 8 * * *", time.Date(2026, 3, 7, 9, 0, 0, 0, newYork), time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+	} {
+		calendar := mustCalendar(t, []string{item.window}, nil, "America/New_York")
+		if got := calendar.NextActive(item.from); !got.Equal(item.want) {
+			t.Errorf("[%v]下一个活跃时间应该是%v, 结果是%v", item.name, item.want, got.UTC())
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"container/heap"
+	"logger"
+	"sort"
+	"sync"
+	"time"
+
+	goerrors "github.com/go-errors/errors"
+)
+
+var log = logger.New("scheduler", logger.Fields{})
+
+// 任务挂掉之后多久重试
+var PanicDelay = 1 * time.Minute
+
+// 任务执行完毕之后返回下一次执行的间隔, 小于等于0表示不再执行
+type Job func() time.Duration
+
+type Task struct {
+	Account string
+	Name    string
+	NextRun time.Time
+	Running bool
+
+	job   Job
+	index int
+	// 执行的时候又被安排了的话, 执行完之后按这个时间重新排队
+	rescheduleAt  time.Time
+	rescheduleJob Job
+	// 执行的时候被取消了, 执行完之后删掉; 还留在tasks里, 这样再安排的时候不会同时执行两个
+	cancelled bool
+}
+
+func (this *Task) Key() string {
+	return this.Account + "/" + this.Name
+}
+
+// 所有账号的任务都放在同一个按执行时间排序的队列里, 由固定数量的Worker来执行
+type Scheduler struct {
+	mu      sync.Mutex
+	queue   taskQueue
+	tasks   map[string]*Task
+	workers int
+	jobs    chan *Task
+	wakeup  chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
+
+	// 任务挂掉的时候调用, 用来发通知
+	OnPanic func(task Task, stack string)
+}
+
+func New(workers int) *Scheduler {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &Scheduler{
+		tasks:   make(map[string]*Task),
+		workers: workers,
+		jobs:    make(chan *Task),
+		wakeup:  make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+}
+
+// 安排任务在指定时间执行, 同一账号的同名任务会被替换;
+// 正在执行的话等它执行完再按新的时间排队, 不会同时执行两个
+func (this *Scheduler) Schedule(account string, name string, at time.Time, job Job) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	task := &Task{Account: account, Name: name, NextRun: at, job: job}
+	if old, ok := this.tasks[task.Key()]; ok {
+		if old.Running {
+			old.rescheduleAt = at
+			old.rescheduleJob = job
+			old.cancelled = false
+		} else {
+			old.NextRun = at
+			old.job = job
+			heap.Fix(&this.queue, old.index)
+		}
+	} else {
+		this.tasks[task.Key()] = task
+		heap.Push(&this.queue, task)
+	}
+
+	this.notify()
+}
+
+// 取消任务, 正在执行的任务会执行完, 但是不会再被安排
+func (this *Scheduler) Cancel(account string, name string) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	key := (&Task{Account: account, Name: name}).Key()
+	task, ok := this.tasks[key]
+	if !ok {
+		return
+	}
+	if task.Running {
+		task.cancelled = true
+		task.rescheduleAt, task.rescheduleJob = time.Time{}, nil
+	} else {
+		delete(this.tasks, key)
+		heap.Remove(&this.queue, task.index)
+	}
+
+	this.notify()
+}
+
+// 所有任务的快照, 按下一次执行时间排序, 正在执行的排在最前面
+func (this *Scheduler) Pending() []Task {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	tasks := make([]Task, 0, len(this.tasks))
+	for _, task := range this.tasks {
+		if !task.cancelled {
+			tasks = append(tasks, *task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Running != tasks[j].Running {
+			return tasks[i].Running
+		}
+		return tasks[i].NextRun.Before(tasks[j].NextRun)
+	})
+
+	return tasks
+}
+
+// 下一个要执行的任务
+func (this *Scheduler) Next() (Task, bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if len(this.queue) == 0 {
+		return Task{}, false
+	}
+	return *this.queue[0], true
+}
+
+// 开始调度, 直到Stop被调用之后才返回
+func (this *Scheduler) Run() {
+	for i := 0; i < this.workers; i++ {
+		this.wg.Add(1)
+		go this.work()
+	}
+	defer func() {
+		close(this.jobs)
+		this.wg.Wait()
+	}()
+
+	for {
+		wait := time.Hour
+		due := []*Task{}
+
+		this.mu.Lock()
+		now := time.Now()
+		for len(this.queue) > 0 && !this.queue[0].NextRun.After(now) {
+			task := heap.Pop(&this.queue).(*Task)
+			task.Running = true
+			due = append(due, task)
+		}
+		if len(this.queue) > 0 {
+			wait = this.queue[0].NextRun.Sub(now)
+		}
+		this.mu.Unlock()
+
+		// Worker都在忙的时候会阻塞在这里, 到期的任务会排队等待
+		for _, task := range due {
+			select {
+			case this.jobs <- task:
+			case <-this.quit:
+				return
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-this.wakeup:
+		case <-this.quit:
+			timer.Stop()
+			return
+		}
+		timer.Stop()
+	}
+}
+
+func (this *Scheduler) Stop() {
+	close(this.quit)
+}
+
+func (this *Scheduler) work() {
+	defer this.wg.Done()
+
+	for task := range this.jobs {
+		delay := this.execute(task)
+
+		this.mu.Lock()
+		task.Running = false
+		if this.tasks[task.Key()] == task {
+			if task.cancelled {
+				delete(this.tasks, task.Key())
+			} else if task.rescheduleJob != nil {
+				task.NextRun, task.job = task.rescheduleAt, task.rescheduleJob
+				task.rescheduleAt, task.rescheduleJob = time.Time{}, nil
+				heap.Push(&this.queue, task)
+			} else if delay > 0 {
+				task.NextRun = time.Now().Add(delay)
+				heap.Push(&this.queue, task)
+			} else {
+				delete(this.tasks, task.Key())
+			}
+		}
+		this.notify()
+		this.mu.Unlock()
+	}
+}
+
+func (this *Scheduler) execute(task *Task) (delay time.Duration) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := goerrors.Wrap(r, 2).ErrorStack()
+			log.Error("任务[%v]挂了: %v", task.Key(), msg)
+			delay = PanicDelay
+			if this.OnPanic != nil {
+				// 执行的时候Schedule也会改task, 复制要加锁
+				this.mu.Lock()
+				snapshot := *task
+				this.mu.Unlock()
+				this.OnPanic(snapshot, msg)
+			}
+		}
+	}()
+
+	return task.job()
+}
+
+func (this *Scheduler) notify() {
+	select {
+	case this.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+type taskQueue []*Task
+
+func (q taskQueue) Len() int {
+	return len(q)
+}
+
+func (q taskQueue) Less(i, j int) bool {
+	return q[i].NextRun.Before(q[j].NextRun)
+}
+
+func (q taskQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *taskQueue) Push(x interface{}) {
+	task := x.(*Task)
+	task.index = len(*q)
+	*q = append(*q, task)
+}
+
+func (q *taskQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil
+	task.index = -1
+	*q = old[:n-1]
+	return task
+}
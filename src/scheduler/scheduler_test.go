@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// 等某件事发生, 超时就失败
+func waitFor(t *testing.T, ch <-chan string, timeout time.Duration) string {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(timeout):
+		t.Fatalf("等了%v还没有执行", timeout)
+		return ""
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	s := New(1)
+	now := time.Now()
+	done := make(chan string, 3)
+	for _, item := range []struct {
+		name  string
+		delay time.Duration
+	}{{"c", -1 * time.Second}, {"a", -3 * time.Second}, {"b", -2 * time.Second}} {
+		name := item.name
+		s.Schedule("account", name, now.Add(item.delay), func() time.Duration {
+			done <- name
+			return 0
+		})
+	}
+
+	next, ok := s.Next()
+	if !ok || next.Name != "a" {
+		t.Fatalf("下一个任务应该是a, 结果是%+v", next)
+	}
+	pending := s.Pending()
+	for i, name := range []string{"a", "b", "c"} {
+		if pending[i].Name != name {
+			t.Errorf("第%v个任务应该是%v, 结果是%v", i, name, pending[i].Name)
+		}
+	}
+
+	go s.Run()
+	defer s.Stop()
+	for _, name := range []string{"a", "b", "c"} {
+		if got := waitFor(t, done, time.Second); got != name {
+			t.Errorf("应该执行%v, 结果执行了%v", name, got)
+		}
+	}
+	if _, ok := s.Next(); ok {
+		t.Errorf("返回0的任务不应该再被安排")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	s := New(1)
+	done := make(chan string, 2)
+	s.Schedule("account", "task", time.Now().Add(time.Hour), func() time.Duration {
+		done <- "old"
+		return 0
+	})
+	s.Schedule("account", "task", time.Now(), func() time.Duration {
+		done <- "new"
+		return 0
+	})
+	if pending := s.Pending(); len(pending) != 1 {
+		t.Fatalf("同名任务应该被替换, 结果有%v个", len(pending))
+	}
+
+	go s.Run()
+	defer s.Stop()
+	if got := waitFor(t, done, time.Second); got != "new" {
+		t.Errorf("应该执行新的任务, 结果执行了%v", got)
+	}
+}
+
+func TestScheduleWhileRunning(t *testing.T) {
+	s := New(2)
+	started, release := make(chan string, 1), make(chan struct{})
+	done := make(chan string, 2)
+	var mu sync.Mutex
+	running, overlapped := 0, false
+	job := func(name string) Job {
+		return func() time.Duration {
+			mu.Lock()
+			running += 1
+			overlapped = overlapped || running > 1
+			mu.Unlock()
+			if name == "first" {
+				started <- name
+				<-release
+			}
+			mu.Lock()
+			running -= 1
+			mu.Unlock()
+			done <- name
+			return time.Hour
+		}
+	}
+
+	s.Schedule("account", "task", time.Now(), job("first"))
+	go s.Run()
+	defer s.Stop()
+	waitFor(t, started, time.Second)
+
+	// 执行的时候再安排, 不能马上又执行一个
+	s.Schedule("account", "task", time.Now(), job("second"))
+	pending := s.Pending()
+	if len(pending) != 1 || !pending[0].Running {
+		t.Fatalf("应该只有一个正在执行的任务, 结果是%+v", pending)
+	}
+	select {
+	case got := <-done:
+		t.Fatalf("第一次还没有执行完, 不应该执行%v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if got := waitFor(t, done, time.Second); got != "first" {
+		t.Fatalf("应该先执行完first, 结果是%v", got)
+	}
+	// 按新安排的时间执行, 而不是first返回的一个小时之后
+	if got := waitFor(t, done, time.Second); got != "second" {
+		t.Fatalf("应该接着执行second, 结果是%v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if overlapped {
+		t.Errorf("同一个任务同时执行了两个")
+	}
+}
+
+func TestCancelWhileRunning(t *testing.T) {
+	s := New(1)
+	started, release := make(chan string, 1), make(chan struct{})
+	runs := make(chan string, 2)
+	s.Schedule("account", "task", time.Now(), func() time.Duration {
+		runs <- "run"
+		started <- "started"
+		<-release
+		return time.Millisecond
+	})
+	go s.Run()
+	defer s.Stop()
+	waitFor(t, started, time.Second)
+	waitFor(t, runs, time.Second)
+
+	s.Cancel("account", "task")
+	close(release)
+	select {
+	case <-runs:
+		t.Fatalf("取消之后不应该再执行")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if pending := s.Pending(); len(pending) != 0 {
+		t.Errorf("取消之后不应该还有任务, 结果是%+v", pending)
+	}
+}
+
+// 停用再恢复账号的时候会这样: 执行的时候取消, 接着又安排, 要等前一个执行完
+func TestCancelThenScheduleWhileRunning(t *testing.T) {
+	s := New(2)
+	started, release := make(chan string, 1), make(chan struct{})
+	done := make(chan string, 2)
+	s.Schedule("account", "task", time.Now(), func() time.Duration {
+		started <- "first"
+		<-release
+		done <- "first"
+		return time.Millisecond
+	})
+	go s.Run()
+	defer s.Stop()
+	waitFor(t, started, time.Second)
+
+	s.Cancel("account", "task")
+	s.Schedule("account", "task", time.Now(), func() time.Duration {
+		done <- "second"
+		return 0
+	})
+	select {
+	case got := <-done:
+		t.Fatalf("第一次还没有执行完, 不应该执行%v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if got := waitFor(t, done, time.Second); got != "first" {
+		t.Fatalf("应该先执行完first, 结果是%v", got)
+	}
+	if got := waitFor(t, done, time.Second); got != "second" {
+		t.Fatalf("应该接着执行second, 结果是%v", got)
+	}
+	// first返回的间隔被忽略, second返回0之后就没有任务了
+	select {
+	case got := <-done:
+		t.Fatalf("不应该再执行%v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if pending := s.Pending(); len(pending) != 0 {
+		t.Errorf("不应该还有任务, 结果是%+v", pending)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	oldDelay := PanicDelay
+	PanicDelay = 10 * time.Millisecond
+	defer func() { PanicDelay = oldDelay }()
+
+	s := New(1)
+	panics := make(chan string, 1)
+	s.OnPanic = func(task Task, stack string) {
+		panics <- task.Key()
+	}
+	done := make(chan string, 1)
+	times := 0
+	s.Schedule("account", "task", time.Now(), func() time.Duration {
+		times += 1
+		if times == 1 {
+			panic("挂了")
+		}
+		done <- "ok"
+		return 0
+	})
+	go s.Run()
+	defer s.Stop()
+
+	if got := waitFor(t, panics, time.Second); got != "account/task" {
+		t.Errorf("OnPanic收到的任务应该是account/task, 结果是%v", got)
+	}
+	// 挂掉之后过PanicDelay重试
+	waitFor(t, done, time.Second)
+}
@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// 配置文件里的凭证可以写成引用, 不用把明文放在配置文件里
+//
+//	env:WALKR_TOKEN_ALICE     读取环境变量
+//	file:/run/secrets/alice   读取文件, 去掉首尾的空白
+//	vault:alice.token         读取加密的本地保险箱
+//
+// 其他的值原样返回
+const (
+	PREFIX_ENV   = "env:"
+	PREFIX_FILE  = "file:"
+	PREFIX_VAULT = "vault:"
+)
+
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, PREFIX_ENV) || strings.HasPrefix(value, PREFIX_FILE) || strings.HasPrefix(value, PREFIX_VAULT)
+}
+
+// 用到vault:引用的时候才需要保险箱, 其他情况可以传nil
+func Resolve(value string, vault *Vault) (string, error) {
+	switch {
+	case strings.HasPrefix(value, PREFIX_ENV):
+		name := strings.TrimPrefix(value, PREFIX_ENV)
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("环境变量[%v]没有设置", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, PREFIX_FILE):
+		path := strings.TrimPrefix(value, PREFIX_FILE)
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取凭证文件[%v]失败: %v", path, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	case strings.HasPrefix(value, PREFIX_VAULT):
+		name := strings.TrimPrefix(value, PREFIX_VAULT)
+		if vault == nil {
+			return "", fmt.Errorf("引用了保险箱里的[%v], 但是没有配置保险箱", name)
+		}
+		return vault.Get(name)
+	}
+
+	return value, nil
+}
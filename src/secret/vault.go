@@ -0,0 +1,235 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 没有指定KeyFile的时候从这个环境变量读取密码
+const PassphraseEnv = "WALKR_VAULT_PASSPHRASE"
+
+// 用密码生成密钥的迭代次数
+var Iterations = 200000
+
+var ErrNotFound = errors.New("保险箱里没有这个凭证")
+
+// 配置文件里的[Vault]
+type Config struct {
+	// 默认 walkr.vault
+	Path string
+	// 密钥文件, 为空则使用密码
+	KeyFile string
+}
+
+type Entry struct {
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 保险箱文件的内容, 凭证用AES-GCM加密
+type sealed struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// 加密的本地保险箱, 保存在一个文件里
+type Vault struct {
+	path    string
+	key     []byte
+	salt    []byte
+	entries map[string]Entry
+}
+
+// 打开保险箱, 文件不存在的话创建一个空的保险箱, 调用Save之后才会写文件
+//
+// passphrase和keyFile只需要一个, keyFile优先
+func Open(config Config, passphrase string) (*Vault, error) {
+	path := config.Path
+	if path == "" {
+		path = "walkr.vault"
+	}
+	vault := &Vault{path: path, entries: make(map[string]Entry)}
+
+	var data sealed
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		vault.salt = make([]byte, 16)
+		if _, err := rand.Read(vault.salt); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("读取保险箱[%v]失败: %v", path, err)
+	} else {
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("保险箱[%v]格式有问题: %v", path, err)
+		}
+		if data.Version != 1 {
+			return nil, fmt.Errorf("不支持的保险箱版本[%v]", data.Version)
+		}
+		vault.salt = data.Salt
+	}
+
+	if vault.key, err = deriveKey(config.KeyFile, passphrase, vault.salt); err != nil {
+		return nil, err
+	}
+	if data.Ciphertext == nil {
+		return vault, nil
+	}
+
+	gcm, err := vault.cipher()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, data.Nonce, data.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("无法打开保险箱, 密码或者密钥文件不正确")
+	}
+	if err := json.Unmarshal(plaintext, &vault.entries); err != nil {
+		return nil, fmt.Errorf("保险箱的内容有问题: %v", err)
+	}
+
+	return vault, nil
+}
+
+func (this *Vault) Get(name string) (string, error) {
+	entry, ok := this.entries[name]
+	if !ok {
+		return "", fmt.Errorf("%v: %v", ErrNotFound, name)
+	}
+	return entry.Value, nil
+}
+
+func (this *Vault) Has(name string) bool {
+	_, ok := this.entries[name]
+	return ok
+}
+
+func (this *Vault) Set(name string, value string) {
+	this.entries[name] = Entry{Value: value, UpdatedAt: time.Now()}
+}
+
+func (this *Vault) Remove(name string) error {
+	if !this.Has(name) {
+		return fmt.Errorf("%v: %v", ErrNotFound, name)
+	}
+	delete(this.entries, name)
+	return nil
+}
+
+// 只返回名称和修改时间, 不包括凭证本身
+func (this *Vault) List() []ListItem {
+	items := []ListItem{}
+	for name, entry := range this.entries {
+		items = append(items, ListItem{Name: name, UpdatedAt: entry.UpdatedAt})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
+
+type ListItem struct {
+	Name      string
+	UpdatedAt time.Time
+}
+
+// 每次保存都用新的nonce, 先写临时文件再替换
+func (this *Vault) Save() error {
+	plaintext, err := json.Marshal(this.entries)
+	if err != nil {
+		return err
+	}
+	gcm, err := this.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data := sealed{Version: 1, Salt: this.salt, Nonce: nonce, Ciphertext: gcm.Seal(nil, nonce, plaintext, nil)}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := this.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, this.path)
+}
+
+func (this *Vault) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(this.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 密钥文件的内容直接用SHA-256生成密钥, 密码用PBKDF2生成密钥
+func deriveKey(keyFile string, passphrase string, salt []byte) ([]byte, error) {
+	if keyFile != "" {
+		b, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥文件[%v]失败: %v", keyFile, err)
+		}
+		content := strings.TrimSpace(string(b))
+		if len(content) < 16 {
+			return nil, fmt.Errorf("密钥文件[%v]太短了", keyFile)
+		}
+		sum := sha256.Sum256(append([]byte(content), salt...))
+		return sum[:], nil
+	}
+
+	if passphrase == "" {
+		return nil, fmt.Errorf("需要设置密钥文件或者环境变量%v", PassphraseEnv)
+	}
+	return pbkdf2([]byte(passphrase), salt, Iterations, 32), nil
+}
+
+// PBKDF2-HMAC-SHA256, RFC 8018
+func pbkdf2(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+
+	key := make([]byte, 0, blocks*hashLength)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, uint32(block))
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:keyLength]
+}
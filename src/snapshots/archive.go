@@ -0,0 +1,228 @@
+package snapshots
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"game"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 按账号保存的游戏数据, 内容一样的只保存一次
+type Snapshot struct {
+	Account string
+	At      time.Time
+	// 压缩之后的JSON的sha1
+	Hash string
+}
+
+type Store interface {
+	// 已经有内容一样的游戏数据的话返回false
+	Save(account string, at time.Time, data []byte) (bool, error)
+	// 按时间排序
+	List(account string) ([]Snapshot, error)
+	Load(snapshot Snapshot) ([]byte, error)
+	Accounts() ([]string, error)
+}
+
+// 空格和换行不算不一样
+func Hash(data []byte) (string, []byte, error) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		return "", nil, fmt.Errorf("游戏数据不是JSON: %v", err)
+	}
+	sum := sha1.Sum(compacted.Bytes())
+	return hex.EncodeToString(sum[:]), compacted.Bytes(), nil
+}
+
+// 读取并解析一份游戏数据
+func LoadData(store Store, snapshot Snapshot) (*game.GameData, error) {
+	data, err := store.Load(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	result, err := game.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析[%v %v]失败: %v", snapshot.Account, formatTime(snapshot.At), err)
+	}
+	return &result.Data, nil
+}
+
+const FILE_TIME_LAYOUT = "20060102T150405Z"
+
+// 目录下每个账号一个子目录, 文件名是时间和sha1, 比如 snapshots/kk/20151227T113936Z-e56ca457c93d6b7a5a49323e50c4d078674e22f0.json
+type FileStore struct {
+	Dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (this *FileStore) Save(account string, at time.Time, data []byte) (bool, error) {
+	hash, compacted, err := Hash(data)
+	if err != nil {
+		return false, err
+	}
+	snapshots, err := this.List(account)
+	if err != nil {
+		return false, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Hash == hash {
+			return false, nil
+		}
+	}
+
+	dir := filepath.Join(this.Dir, account)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+	name := fmt.Sprintf("%v-%v.json", at.UTC().Format(FILE_TIME_LAYOUT), hash)
+	if err := ioutil.WriteFile(filepath.Join(dir, name), compacted, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (this *FileStore) List(account string) ([]Snapshot, error) {
+	files, err := ioutil.ReadDir(filepath.Join(this.Dir, account))
+	if os.IsNotExist(err) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		parts := strings.SplitN(name, "-", 2)
+		if file.IsDir() || name == file.Name() || len(parts) != 2 {
+			continue
+		}
+		at, err := time.Parse(FILE_TIME_LAYOUT, parts[0])
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Account: account, At: at, Hash: parts[1]})
+	}
+	sortSnapshots(snapshots)
+	return snapshots, nil
+}
+
+func (this *FileStore) Load(snapshot Snapshot) ([]byte, error) {
+	name := fmt.Sprintf("%v-%v.json", snapshot.At.UTC().Format(FILE_TIME_LAYOUT), snapshot.Hash)
+	return ioutil.ReadFile(filepath.Join(this.Dir, snapshot.Account, name))
+}
+
+func (this *FileStore) Accounts() ([]string, error) {
+	files, err := ioutil.ReadDir(this.Dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			accounts = append(accounts, file.Name())
+		}
+	}
+	return accounts, nil
+}
+
+const (
+	// 所有账号
+	AccountsKey = "archive:accounts"
+	// 有序集合, 成员是sha1, 分数是Unix秒数
+	SnapshotsKey = "archive:%v"
+	// 游戏数据的内容
+	DataKey = "archive:%v:%v"
+)
+
+type RedisStore struct {
+	redis *goredis.Client
+}
+
+func NewRedisStore(redis *goredis.Client) *RedisStore {
+	return &RedisStore{redis: redis}
+}
+
+func (this *RedisStore) Save(account string, at time.Time, data []byte) (bool, error) {
+	hash, compacted, err := Hash(data)
+	if err != nil {
+		return false, err
+	}
+	// 以有序集合为准, 最后才加进去; 中途失败的话下次会重新写一遍
+	snapshotsKey := fmt.Sprintf(SnapshotsKey, account)
+	err = this.redis.ZScore(snapshotsKey, hash).Err()
+	if err == nil {
+		return false, nil
+	}
+	if err != goredis.Nil {
+		return false, err
+	}
+
+	if err := this.redis.Set(fmt.Sprintf(DataKey, account, hash), string(compacted)).Err(); err != nil {
+		return false, err
+	}
+	if err := this.redis.SAdd(AccountsKey, account).Err(); err != nil {
+		return false, err
+	}
+	member := goredis.Z{Score: float64(at.Unix()), Member: hash}
+	if err := this.redis.ZAdd(snapshotsKey, member).Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (this *RedisStore) List(account string) ([]Snapshot, error) {
+	members, err := this.redis.ZRangeWithScores(fmt.Sprintf(SnapshotsKey, account), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, member := range members {
+		snapshots = append(snapshots, Snapshot{Account: account, At: time.Unix(int64(member.Score), 0).UTC(), Hash: member.Member})
+	}
+	sortSnapshots(snapshots)
+	return snapshots, nil
+}
+
+func (this *RedisStore) Load(snapshot Snapshot) ([]byte, error) {
+	value, err := this.redis.Get(fmt.Sprintf(DataKey, snapshot.Account, snapshot.Hash)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (this *RedisStore) Accounts() ([]string, error) {
+	accounts, err := this.redis.SMembers(AccountsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(accounts)
+	return accounts, nil
+}
+
+func sortSnapshots(snapshots []Snapshot) {
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].At.Before(snapshots[j].At) })
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
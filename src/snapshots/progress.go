@@ -0,0 +1,211 @@
+package snapshots
+
+import (
+	"encoding/json"
+	"fmt"
+	"game"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// 比较一个账号连续的几份游戏数据, 看成就的进度和任务有没有在动, 用来决定哪些账号需要手动玩一下
+type Progress struct {
+	Account   string    `json:"account"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Snapshots int       `json:"snapshots"`
+	// 这段时间里新完成的成就, 以及平均每天完成几个
+	Completed       int                   `json:"completed"`
+	CompletedPerDay float64               `json:"completed_per_day"`
+	Achievements    []AchievementProgress `json:"achievements"`
+	StalledMissions []StalledMission      `json:"stalled_missions"`
+}
+
+// 没有完成的成就, Velocity是平均每天增加的进度, 按这个速度估计完成的时间, 不动的话ETA是零值
+type AchievementProgress struct {
+	Identifier string    `json:"identifier"`
+	Progress   float64   `json:"progress"`
+	Velocity   float64   `json:"velocity"`
+	ETA        time.Time `json:"eta"`
+}
+
+// 进行中的任务, 从Since开始资源和更新时间都没有变过
+type StalledMission struct {
+	Identifier string    `json:"identifier"`
+	ResourceA  int       `json:"resource_a"`
+	ResourceB  int       `json:"resource_b"`
+	ResourceC  int       `json:"resource_c"`
+	Since      time.Time `json:"since"`
+	// 到最后一份游戏数据停了多少秒
+	Stalled int64 `json:"stalled"`
+}
+
+const DAY = 24 * time.Hour
+
+// window是最多看最近几份游戏数据, 0是全部; 进行中的任务超过stall没有变化算是停滞了
+func Track(store Store, account string, window int, stall time.Duration) (Progress, error) {
+	list, err := store.List(account)
+	if err != nil {
+		return Progress{}, err
+	}
+	if window > 0 && len(list) > window {
+		list = list[len(list)-window:]
+	}
+
+	history := []*game.GameData{}
+	for _, snapshot := range list {
+		data, err := LoadData(store, snapshot)
+		if err != nil {
+			return Progress{}, err
+		}
+		history = append(history, data)
+	}
+	return NewProgress(account, list, history, stall), nil
+}
+
+// list和history一一对应, 按时间排序
+func NewProgress(account string, list []Snapshot, history []*game.GameData, stall time.Duration) Progress {
+	progress := Progress{
+		Account:         account,
+		Snapshots:       len(list),
+		Achievements:    []AchievementProgress{},
+		StalledMissions: []StalledMission{},
+	}
+	if len(list) == 0 {
+		return progress
+	}
+
+	first, last := history[0], history[len(history)-1]
+	progress.From, progress.To = list[0].At, list[len(list)-1].At
+	days := float64(progress.To.Sub(progress.From)) / float64(DAY)
+
+	before := make(map[string]game.Achievement)
+	for _, achievement := range first.Achievements {
+		before[achievement.Identifier] = achievement
+	}
+	for _, achievement := range last.Achievements {
+		old, ok := before[achievement.Identifier]
+		if achievement.Completed {
+			if !ok || !old.Completed {
+				progress.Completed += 1
+			}
+			continue
+		}
+
+		item := AchievementProgress{Identifier: achievement.Identifier, Progress: achievement.Progress}
+		if ok && days > 0 {
+			item.Velocity = (achievement.Progress - old.Progress) / days
+		}
+		if item.Velocity > 0 {
+			remaining := (1 - achievement.Progress) / item.Velocity
+			item.ETA = progress.To.Add(time.Duration(remaining * float64(DAY)))
+		}
+		progress.Achievements = append(progress.Achievements, item)
+	}
+	if days > 0 {
+		progress.CompletedPerDay = float64(progress.Completed) / days
+	}
+	// 快完成的排前面, 不动的排最后
+	sort.SliceStable(progress.Achievements, func(i, j int) bool {
+		a, b := progress.Achievements[i], progress.Achievements[j]
+		if a.ETA.IsZero() != b.ETA.IsZero() {
+			return !a.ETA.IsZero()
+		}
+		if a.ETA.IsZero() {
+			return a.Progress > b.Progress
+		}
+		return a.ETA.Before(b.ETA)
+	})
+
+	for _, mission := range last.Missions {
+		if mission.Completed || mission.Aborted {
+			continue
+		}
+		// 往前找到任务最早一次是现在这个样子的游戏数据
+		since, changed := list[len(list)-1].At, false
+		for i := len(history) - 2; i >= 0 && !changed; i-- {
+			old, ok := findMission(history[i], mission.Identifier)
+			if changed = !ok || !sameMission(old, mission); !changed {
+				since = list[i].At
+			}
+		}
+		// 所有游戏数据里都没变过的话, 任务的更新时间可能更早
+		if !changed && !mission.UpdatedAt.IsZero() && mission.UpdatedAt.Before(since) {
+			since = mission.UpdatedAt
+		}
+
+		stalled := progress.To.Sub(since)
+		if stalled >= stall {
+			progress.StalledMissions = append(progress.StalledMissions, StalledMission{
+				Identifier: mission.Identifier,
+				ResourceA:  mission.ResourceA,
+				ResourceB:  mission.ResourceB,
+				ResourceC:  mission.ResourceC,
+				Since:      since,
+				Stalled:    int64(stalled / time.Second),
+			})
+		}
+	}
+	sort.SliceStable(progress.StalledMissions, func(i, j int) bool {
+		return progress.StalledMissions[i].Stalled > progress.StalledMissions[j].Stalled
+	})
+
+	return progress
+}
+
+func findMission(data *game.GameData, identifier string) (game.Mission, bool) {
+	for _, mission := range data.Missions {
+		if mission.Identifier == identifier {
+			return mission, true
+		}
+	}
+	return game.Mission{}, false
+}
+
+func sameMission(a, b game.Mission) bool {
+	return a.Completed == b.Completed && a.Aborted == b.Aborted &&
+		a.ResourceA == b.ResourceA && a.ResourceB == b.ResourceB && a.ResourceC == b.ResourceC &&
+		a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+func WriteProgress(w io.Writer, format string, progresses []Progress) error {
+	switch format {
+	case "table":
+		return WriteProgressTable(w, progresses)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(progresses)
+	}
+
+	return fmt.Errorf("不支持的输出格式[%v]", format)
+}
+
+func WriteProgressTable(w io.Writer, progresses []Progress) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, progress := range progresses {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "账号: %v, %v份游戏数据, %v - %v\n", progress.Account, progress.Snapshots, formatTime(progress.From), formatTime(progress.To))
+		fmt.Fprintf(tw, "新完成的成就: %v个, 平均每天%.2f个\n", progress.Completed, progress.CompletedPerDay)
+
+		fmt.Fprintln(tw, "成就\t进度\t每天\t预计完成")
+		for _, achievement := range progress.Achievements {
+			eta := "不动"
+			if !achievement.ETA.IsZero() {
+				eta = formatTime(achievement.ETA)
+			}
+			fmt.Fprintf(tw, "%v\t%.1f%%\t%+.2f%%\t%v\n", achievement.Identifier, achievement.Progress*100, achievement.Velocity*100, eta)
+		}
+
+		fmt.Fprintln(tw, "停滞的任务\t资源A\t资源B\t资源C\t开始时间\t停了多久")
+		for _, mission := range progress.StalledMissions {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", mission.Identifier, mission.ResourceA, mission.ResourceB, mission.ResourceC,
+				formatTime(mission.Since), time.Duration(mission.Stalled)*time.Second)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,190 @@
+package snapshots
+
+import (
+	"encoding/csv"
+	"fmt"
+	"game"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 一份游戏数据里的主要数值
+type Point struct {
+	At         time.Time
+	Coins      int64
+	Cubes      int
+	Energy     int
+	Population int
+	Level      int
+	Colonies   int
+}
+
+func NewPoint(at time.Time, data *game.GameData) Point {
+	return Point{
+		At:         at,
+		Coins:      data.Coins,
+		Cubes:      data.Cubes,
+		Energy:     data.Energy,
+		Population: data.Population,
+		Level:      data.Level,
+		Colonies:   len(data.Colonies),
+	}
+}
+
+// 账号所有游戏数据按时间排列
+func Series(store Store, account string) ([]Point, error) {
+	snapshots, err := store.List(account)
+	if err != nil {
+		return nil, err
+	}
+
+	points := []Point{}
+	for _, snapshot := range snapshots {
+		data, err := LoadData(store, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, NewPoint(snapshot.At, data))
+	}
+	return points, nil
+}
+
+// 图表和CSV里的每一列
+var metrics = []struct {
+	name  string
+	value func(point Point) float64
+}{
+	{"coins", func(point Point) float64 { return float64(point.Coins) }},
+	{"cubes", func(point Point) float64 { return float64(point.Cubes) }},
+	{"energy", func(point Point) float64 { return float64(point.Energy) }},
+	{"population", func(point Point) float64 { return float64(point.Population) }},
+	{"level", func(point Point) float64 { return float64(point.Level) }},
+	{"colonies", func(point Point) float64 { return float64(point.Colonies) }},
+}
+
+func WriteCSV(w io.Writer, points []Point) error {
+	writer := csv.NewWriter(w)
+	header := []string{"time"}
+	for _, metric := range metrics {
+		header = append(header, metric.name)
+	}
+	writer.Write(header)
+	for _, point := range points {
+		row := []string{formatTime(point.At)}
+		for _, metric := range metrics {
+			row = append(row, strconv.FormatFloat(metric.value(point), 'f', -1, 64))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+const (
+	CHART_WIDTH  = 640
+	CHART_HEIGHT = 160
+	CHART_MARGIN = 10
+)
+
+type chart struct {
+	Name   string
+	Min    string
+	Max    string
+	Last   string
+	Points string
+	Dots   []chartDot
+}
+
+type chartDot struct {
+	X, Y  float64
+	Title string
+}
+
+// 不依赖任何外部文件的页面, 每个数值一张折线图
+var chartTemplate = template.Must(template.New("chart").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Account}}</title>
+<style>
+body { font-family: sans-serif; background: #f7f7f7; color: #333; }
+.chart { background: #fff; margin: 12px 0; padding: 8px; display: inline-block; }
+.chart h2 { font-size: 14px; margin: 0 0 4px; }
+.chart span { color: #888; font-size: 12px; margin-left: 8px; }
+</style>
+</head>
+<body>
+<h1>{{.Account}}</h1>
+<p>{{.From}} - {{.To}}, 共{{.Count}}份游戏数据</p>
+{{range .Charts}}<div class="chart">
+<h2>{{.Name}}<span>最新 {{.Last}}, 最小 {{.Min}}, 最大 {{.Max}}</span></h2>
+<svg width="{{$.Width}}" height="{{$.Height}}">
+<polyline points="{{.Points}}" fill="none" stroke="#3b82c4" stroke-width="2"/>
+{{range .Dots}}<circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="3" fill="#3b82c4"><title>{{.Title}}</title></circle>
+{{end}}</svg>
+</div>
+{{end}}</body>
+</html>
+`))
+
+func WriteHTML(w io.Writer, account string, points []Point) error {
+	view := struct {
+		Account string
+		From    string
+		To      string
+		Count   int
+		Width   int
+		Height  int
+		Charts  []chart
+	}{Account: account, Count: len(points), Width: CHART_WIDTH, Height: CHART_HEIGHT}
+	if len(points) > 0 {
+		view.From, view.To = formatTime(points[0].At), formatTime(points[len(points)-1].At)
+	}
+
+	for _, metric := range metrics {
+		view.Charts = append(view.Charts, newChart(metric.name, metric.value, points))
+	}
+	return chartTemplate.Execute(w, view)
+}
+
+// 横坐标按时间, 纵坐标按最小值到最大值
+func newChart(name string, value func(point Point) float64, points []Point) chart {
+	c := chart{Name: name}
+	if len(points) == 0 {
+		return c
+	}
+
+	min, max := value(points[0]), value(points[0])
+	for _, point := range points {
+		if v := value(point); v < min {
+			min = v
+		} else if v > max {
+			max = v
+		}
+	}
+	from, to := points[0].At, points[len(points)-1].At
+
+	coordinates := []string{}
+	for _, point := range points {
+		x := float64(CHART_WIDTH) / 2
+		if to.After(from) {
+			x = CHART_MARGIN + float64(point.At.Sub(from))/float64(to.Sub(from))*(CHART_WIDTH-2*CHART_MARGIN)
+		}
+		y := float64(CHART_HEIGHT) / 2
+		if max > min {
+			y = CHART_HEIGHT - CHART_MARGIN - (value(point)-min)/(max-min)*(CHART_HEIGHT-2*CHART_MARGIN)
+		}
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, y))
+		c.Dots = append(c.Dots, chartDot{X: x, Y: y, Title: fmt.Sprintf("%v %v", formatTime(point.At), formatValue(value(point)))})
+	}
+	c.Points = strings.Join(coordinates, " ")
+	c.Min, c.Max, c.Last = formatValue(min), formatValue(max), formatValue(value(points[len(points)-1]))
+	return c
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
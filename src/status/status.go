@@ -0,0 +1,133 @@
+package status
+
+import (
+	"admin"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+// 运行中的帮飞程序把账号状态和事件写到Redis里, 其他进程可以直接读取
+const (
+	StatusKey = "epic:status"
+	EventsKey = "epic:events"
+)
+
+// 最多保留多少条事件
+var MaxEvents int64 = 500
+
+func SaveAccounts(redis *goredis.Client, accounts []admin.Account) error {
+	for _, account := range accounts {
+		b, err := json.Marshal(account)
+		if err != nil {
+			return err
+		}
+		if err := redis.HSet(StatusKey, strconv.Itoa(account.PlayerId), string(b)).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func LoadAccounts(redis *goredis.Client) ([]admin.Account, error) {
+	values, err := redis.HGetAllMap(StatusKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []admin.Account{}
+	for playerId, value := range values {
+		var account admin.Account
+		if err := json.Unmarshal([]byte(value), &account); err != nil {
+			return nil, fmt.Errorf("解析账号[%v]的状态失败: %v", playerId, err)
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	return accounts, nil
+}
+
+// 删除已经不在运行的账号
+func RemoveAccount(redis *goredis.Client, playerId int) error {
+	return redis.HDel(StatusKey, strconv.Itoa(playerId)).Err()
+}
+
+func PushEvent(redis *goredis.Client, event admin.Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := redis.LPush(EventsKey, string(b)).Err(); err != nil {
+		return err
+	}
+
+	return redis.LTrim(EventsKey, 0, MaxEvents-1).Err()
+}
+
+// 最近的n条事件, 按时间先后排序
+func LoadEvents(redis *goredis.Client, n int) ([]admin.Event, error) {
+	values, err := redis.LRange(EventsKey, 0, int64(n)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]admin.Event, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		var event admin.Event
+		if err := json.Unmarshal([]byte(values[i]), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// AuthToken或者Cookie失效之后停用的账号, 配置文件里的凭证更新之后自动恢复
+const SuspendedKey = "epic:suspended"
+
+type Suspension struct {
+	Account     string    `json:"account"`
+	PlayerId    int       `json:"player_id"`
+	Reason      string    `json:"reason"`
+	SuspendedAt time.Time `json:"suspended_at"`
+	// 停用时凭证的指纹, 不保存凭证本身
+	Fingerprint string `json:"fingerprint"`
+}
+
+func SaveSuspension(redis *goredis.Client, suspension Suspension) error {
+	b, err := json.Marshal(suspension)
+	if err != nil {
+		return err
+	}
+
+	return redis.HSet(SuspendedKey, strconv.Itoa(suspension.PlayerId), string(b)).Err()
+}
+
+// 没有停用的话返回nil
+func LoadSuspension(redis *goredis.Client, playerId int) (*Suspension, error) {
+	value, err := redis.HGet(SuspendedKey, strconv.Itoa(playerId)).Result()
+	if err == goredis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var suspension Suspension
+	if err := json.Unmarshal([]byte(value), &suspension); err != nil {
+		return nil, fmt.Errorf("解析账号[%v]的停用记录失败: %v", playerId, err)
+	}
+	return &suspension, nil
+}
+
+func RemoveSuspension(redis *goredis.Client, playerId int) error {
+	return redis.HDel(SuspendedKey, strconv.Itoa(playerId)).Err()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"api"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+	"time"
+	"utils"
+
+	goerrors "github.com/go-errors/errors"
+)
+
+var EnergyRoundDuration = 10 * time.Minute
+
+type ConvertEnergyRequest struct {
+	AuthToken       string `json:"auth_token"`
+	ClientVersion   string `json:"client_version"`
+	Platform        string `json:"platform"`
+	ConvertedEnergy int    `json:"converted_energy,string"`
+}
+
+func _energyLoop(playerInfo api.PlayerInfo) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := goerrors.Wrap(r, 2).ErrorStack()
+			playerInfo.Log().Error("程序挂了: %v", msg)
+		}
+	}()
+
+	for {
+		_convertEnegeryToPilots(playerInfo)
+
+		_incrEnergyRound(playerInfo)
+		time.Sleep(EnergyRoundDuration)
+	}
+}
+
+func _convertEnegeryToPilots(playerInfo api.PlayerInfo) bool {
+	convertEnergyRequestJson := ConvertEnergyRequest{
+		AuthToken:       playerInfo.AuthToken,
+		ClientVersion:   playerInfo.ClientVersion,
+		Platform:        playerInfo.Platform,
+		ConvertedEnergy: _generateEnergy(),
+	}
+	b, err := json.Marshal(convertEnergyRequestJson)
+	if err != nil {
+		playerInfo.Log().Error("转换数据格式错误: %v", err)
+		return false
+	}
+
+	host := "https://universe.walkrgame.com/api/v1/pilots/convert"
+	req, err := utils.GenerateWalkrRequest(host, "POST", playerInfo.Cookie, bytes.NewBuffer([]byte(b)))
+	if err != nil {
+		playerInfo.Log().Error("创建请求出错: %v", err)
+		return false
+
+	}
+
+	if resp, err := api.HttpClient.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			playerInfo.Log().Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record api.BoolResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			playerInfo.Log().Error("刷新能量失败: %v", err)
+			return false
+		}
+
+		if record.Success == true {
+			playerInfo.Log().Notice("第%v轮刷新能量成功, 转换能量%v", _energyRound(playerInfo), convertEnergyRequestJson.ConvertedEnergy)
+		} else {
+			playerInfo.Log().Warning("刷新能量失败, 转换能量%v", convertEnergyRequestJson.ConvertedEnergy)
+		}
+
+		return true
+
+	} else {
+		playerInfo.Log().Error("创建请求失败: %v", err)
+		return false
+
+	}
+}
+
+func _generateEnergy() int {
+	return rand.New(rand.NewSource(time.Now().UnixNano())).Intn(10000) + 50000
+}
+
+// BI相关
+func _energyRound(playerInfo api.PlayerInfo) int {
+	roundKey := "energy:round"
+
+	currentRound, err := strconv.Atoi(redis.HGet(roundKey, strconv.Itoa(playerInfo.PlayerId())).Val())
+	if err != nil || currentRound <= 0 {
+		currentRound = 1
+	}
+
+	return currentRound
+}
+
+func _incrEnergyRound(playerInfo api.PlayerInfo) {
+	roundKey := "energy:round"
+	redis.HIncrBy(roundKey, strconv.Itoa(playerInfo.PlayerId()), 1)
+}
+
+// 每个账号定时转换舰桥能量: walkr energy
+func runEnergy(args []string) {
+	flags := _newFlagSet("energy", "")
+	flags.Parse(args)
+
+	conf, err := _loadConfig()
+	if err != nil {
+		log.Error("%v", err)
+		return
+	}
+
+	for _, playerInfo := range conf.PlayerInfo {
+		go _energyLoop(playerInfo)
+	}
+	select {}
+}
@@ -0,0 +1,1850 @@
+package main
+
+import (
+	"admin"
+	"api"
+	"bufio"
+	"bytes"
+	"config"
+	"dashboard"
+	"encoding/json"
+	"fmt"
+	"history"
+	"io"
+	"io/ioutil"
+	"logger"
+	"math"
+	"metrics"
+	"net/http"
+	"net/url"
+	"notify"
+	"os"
+	"os/signal"
+	"quota"
+	"reflect"
+	"scheduler"
+	"secret"
+	"sort"
+	"status"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"utils"
+
+	"github.com/BurntSushi/toml"
+)
+
+var conf config.Config
+var leaveComments LeaveComments
+
+var RoundDuration = 1 * time.Minute
+var WaitDuration = 5 * time.Minute
+var FriendDuration = 2 * time.Minute
+var ScheduleLogDuration = 5 * time.Minute
+var StatusDuration = 5 * time.Second
+var MaxJoinedTimes = 5
+var MaxUnauthorized = 3
+var MaxLeaveAttempts = 5
+var LeaveRetryDuration = 5 * time.Second
+var ConfigCheckDuration = 30 * time.Second
+var FleetInvitationCount = make(map[int]int)
+var sched *scheduler.Scheduler
+var helpers = make(map[int]*Helper)
+var helpersMu sync.RWMutex
+var forceStart bool
+var notifier *notify.Notifier
+
+const (
+	COMMENT_JOINED = "我进来啦，我会在五分钟之后自动退队。如果退队的时候还没有捐献完毕，不要着急，重新邀请就好。不过请记住，同一舰队邀请数量达到五次，我会忽略邀请的。谢谢!"
+	COMMENT_LEAVE  = "关于离开舰队, 大家有话说."
+)
+
+const (
+	TASK_INVITATION = "invitation"
+	TASK_LEAVE      = "leave"
+	TASK_FRIEND     = "friend"
+	TASK_SCHEDULE   = "schedule"
+	TASK_STATUS     = "status"
+	TASK_CONFIG     = "config"
+	TASK_RELOAD     = "reload"
+)
+
+type LeaveComments struct {
+	List []string
+}
+
+type CommentRequest struct {
+	AuthToken     string `json:"auth_token"`
+	ClientVersion string `json:"client_version"`
+	Platform      string `json:"platform"`
+	Locale        string `json:"locale"`
+	Text          string `json:"text"`
+}
+
+type CurrentEpicResponse struct {
+	Success bool   `json:"success"`
+	FleetId int    `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// 1. 传说列表Resp
+type EpicListResponse struct {
+	Epics []Epic `json:"epics"`
+}
+type Epic struct {
+	Id               int    `json:"id"`
+	Name             string `json:"name"`
+	InvitationCounts int    `json:"invitation_counts"`
+}
+
+// 2. 飞传说中的舰队列表Resp
+type FleetListResponse struct {
+	Fleets []Fleet `json:"fleets"`
+}
+type Fleet struct {
+	Id        int     `json:"id"`
+	Name      string  `json:"name"`
+	IsInvited bool    `json:"is_invited"`
+	Captain   Captain `json:"captain"`
+	Quality   int
+	// 所属的传说, 查看邀请的时候填上
+	EpicId int `json:"-"`
+}
+type Captain struct {
+	Name string `json:"name"`
+}
+
+// 3. 舰队详细信息
+type FleetDetailInfo struct {
+	Id      int      `json:"id"`
+	Name    string   `json:"name"`
+	EpicId  int      `json:"epic_id"`
+	Members []Member `json:"members"`
+}
+type Member struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// 帮飞号运行时的信息, 任务和管理接口会同时访问;
+// PlayerInfo、Calendar和Quota重新加载配置的时候会在mu里替换, 要通过snapshot读取, 只有Name不会变
+type Helper struct {
+	PlayerInfo api.PlayerInfo
+	Calendar   *scheduler.Calendar
+	Quota      *quota.Tracker
+
+	mu           sync.Mutex
+	state        string
+	paused       bool
+	fleet        *Fleet
+	record       *history.Record
+	leaveAt      time.Time
+	waitDuration time.Duration
+	lastError    string
+	suspended    bool
+	unauthorized int
+	stopping     bool
+}
+
+// 记录帮飞过程中的事件, 状态面板会显示出来, 错误会记为最近错误
+func (this *Helper) Event(level string, format string, args ...interface{}) {
+	message := logger.Redact(fmt.Sprintf(format, args...))
+	if level == "ERROR" {
+		this.mu.Lock()
+		this.lastError = message
+		this.mu.Unlock()
+	}
+
+	event := admin.Event{Time: time.Now(), Account: this.PlayerInfo.Name, Level: level, Message: message}
+	if err := status.PushEvent(redis, event); err != nil {
+		log.Error("保存事件失败: %v", err)
+	}
+}
+
+func (this *Helper) SetState(state string) {
+	this.mu.Lock()
+	this.state = state
+	this.mu.Unlock()
+
+	metrics.SetAccountState(this.PlayerInfo.Name, state)
+}
+
+func (this *Helper) snapshot() (api.PlayerInfo, *scheduler.Calendar, *quota.Tracker) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.PlayerInfo, this.Calendar, this.Quota
+}
+
+func (this *Helper) WaitDuration() time.Duration {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.waitDuration
+}
+
+func (this *Helper) IsPaused() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.paused
+}
+
+// 账号已经从配置文件里删除, 离开当前舰队之后停止
+func (this *Helper) IsStopping() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.stopping
+}
+
+func (this *Helper) IsSuspended() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.suspended
+}
+
+// 返回连续被拒绝的次数
+func (this *Helper) recordUnauthorized() int {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.unauthorized += 1
+	return this.unauthorized
+}
+
+func (this *Helper) resetUnauthorized() {
+	this.mu.Lock()
+	this.unauthorized = 0
+	this.mu.Unlock()
+}
+
+// 记录当前所在的舰队
+func (this *Helper) joinFleet(fleet *Fleet, record *history.Record, leaveAt time.Time) {
+	this.mu.Lock()
+	this.fleet = fleet
+	this.record = record
+	this.leaveAt = leaveAt
+	this.mu.Unlock()
+
+	this.SetState(metrics.STATE_IN_FLEET)
+}
+
+// 取出当前所在的舰队, 由离队任务负责离开
+func (this *Helper) takeFleet() (*Fleet, *history.Record) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	fleet, record := this.fleet, this.record
+	this.fleet = nil
+	this.record = nil
+	this.leaveAt = time.Time{}
+
+	return fleet, record
+}
+
+func NewHelper(playerInfo api.PlayerInfo) (*Helper, error) {
+	calendar, tracker, err := _newSchedule(playerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	helper := &Helper{PlayerInfo: playerInfo, Calendar: calendar, Quota: tracker, waitDuration: WaitDuration}
+	helper.SetState(metrics.STATE_IDLE)
+
+	return helper, nil
+}
+
+// 根据账号配置生成活跃时间和配额, 重新加载配置的时候也用来检查配置
+func _newSchedule(playerInfo api.PlayerInfo) (*scheduler.Calendar, *quota.Tracker, error) {
+	calendar, err := scheduler.NewCalendar(playerInfo.ActiveWindows, playerInfo.BlackoutDates, playerInfo.TimeZone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limits := quota.Limits{
+		FleetsPerHour:      playerInfo.MaxFleetsPerHour,
+		FleetsPerDay:       playerInfo.MaxFleetsPerDay,
+		FleetMinutesPerDay: playerInfo.MaxFleetMinutesPerDay,
+	}
+	tracker, err := quota.NewTracker(redis, playerInfo.PlayerId(), limits, playerInfo.QuotaResetAt, calendar.Location())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return calendar, tracker, nil
+}
+
+// 创建账号并安排任务, 停用的账号凭证没有更新的话继续停用
+func _startHelper(playerInfo api.PlayerInfo, now time.Time) (*Helper, error) {
+	helper, err := NewHelper(playerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 放进helpers之前设置好停用状态, 之后管理接口和任务都会加锁读取
+	suspended := false
+	suspension, err := status.LoadSuspension(redis, playerInfo.PlayerId())
+	if err != nil {
+		playerInfo.Log().Error("读取停用记录失败: %v", err)
+	} else if suspension != nil && suspension.Fingerprint == playerInfo.Fingerprint() {
+		playerInfo.Log().Warning("账号在%v因为凭证失效(%v)停用, 更新配置文件之后自动恢复", suspension.SuspendedAt.Format("2006-01-02 15:04"), suspension.Reason)
+		helper.suspended, suspended = true, true
+		helper.SetState(metrics.STATE_SUSPENDED)
+	} else if suspension != nil {
+		playerInfo.Log().Notice("凭证已经更新, 恢复停用的账号")
+		status.RemoveSuspension(redis, playerInfo.PlayerId())
+	}
+
+	helpersMu.Lock()
+	helpers[playerInfo.PlayerId()] = helper
+	helpersMu.Unlock()
+	if suspended {
+		return helper, nil
+	}
+
+	if playerInfo.EpicHelper {
+		_scheduleInvitation(helper, now)
+	}
+	_scheduleFriend(helper, now)
+
+	return helper, nil
+}
+
+// 取消账号的任务, 在舰队里的话等离队任务离开之后再删除
+func _stopHelper(helper *Helper) {
+	helper.mu.Lock()
+	helper.stopping = true
+	inFleet := helper.fleet != nil
+	playerInfo := helper.PlayerInfo
+	helper.mu.Unlock()
+
+	sched.Cancel(playerInfo.Name, TASK_INVITATION)
+	sched.Cancel(playerInfo.Name, TASK_FRIEND)
+	if inFleet {
+		playerInfo.Log().Notice("账号已经从配置文件里删除, 离开当前舰队之后停止")
+		helper.Event("NOTICE", "账号已经从配置文件里删除, 离开当前舰队之后停止")
+		return
+	}
+
+	_removeHelper(helper)
+}
+
+func _removeHelper(helper *Helper) {
+	playerInfo, _, _ := helper.snapshot()
+	playerId := playerInfo.PlayerId()
+
+	helpersMu.Lock()
+	if helpers[playerId] == helper {
+		delete(helpers, playerId)
+	}
+	helpersMu.Unlock()
+
+	status.RemoveAccount(redis, playerId)
+	playerInfo.Log().Notice("账号已经停止")
+	helper.Event("NOTICE", "账号已经停止")
+}
+
+// 查看传说邀请, 加入之后安排离队任务
+func _checkInvitation(helper *Helper) time.Duration {
+	playerInfo, calendar, tracker := helper.snapshot()
+
+	// 1. 获取传说列表
+	// 2. 获取舰队列表
+	// 3. 加入邀请的舰队
+	// 4. 留言说明几分钟退出
+	// 5. 到时间之后由离队任务退出舰队
+	// 暂停之后不再查看邀请, 恢复的时候重新安排
+	if helper.IsSuspended() || helper.IsStopping() {
+		return 0
+	}
+	if helper.IsPaused() {
+		playerInfo.Log().Notice("已经暂停帮飞")
+		helper.SetState(metrics.STATE_PAUSED)
+		helper.Event("NOTICE", "已经暂停帮飞")
+		return 0
+	}
+
+	currentRound := _getRound(playerInfo)
+	playerInfo.Log().Warning("=====================第%v次循环 =====================", currentRound)
+
+	// 如果循环开始还有运行的传说，则退出
+	_leaveCurrentEpicIfExists(playerInfo)
+
+	// 不在活跃时间内的话等到下一个活跃时间再查看邀请
+	if now := time.Now(); !calendar.Active(now) {
+		next := calendar.NextActive(now)
+		playerInfo.Log().Notice("当前不在活跃时间内, 将在%v恢复帮飞", next.Format("2006-01-02 15:04"))
+		helper.SetState(metrics.STATE_PAUSED)
+		helper.Event("NOTICE", "不在活跃时间内, 将在%v恢复帮飞", next.Format("2006-01-02 15:04"))
+		return next.Sub(now)
+	}
+
+	// 配额用完之后暂停到配额重置
+	now := time.Now()
+	waitDuration := helper.WaitDuration()
+	remaining := tracker.Remaining(now)
+	if !remaining.Allows(waitDuration) {
+		resume := tracker.ResumeAt(now, waitDuration)
+		playerInfo.Log().Notice("帮飞配额已经用完(%v), 将在%v恢复帮飞", remaining, resume.Format("2006-01-02 15:04"))
+		helper.SetState(metrics.STATE_PAUSED)
+		helper.Event("NOTICE", "帮飞配额已经用完(%v), 将在%v恢复帮飞", remaining, resume.Format("2006-01-02 15:04"))
+		notifier.Send(notify.EVENT_QUOTA_EXHAUSTED, playerInfo.Name, playerInfo.PlayerId(),
+			"帮飞配额已经用完(%v), 将在%v恢复帮飞", remaining, resume.Format("2006-01-02 15:04"))
+		return resume.Sub(now)
+	}
+	helper.SetState(metrics.STATE_IDLE)
+	playerInfo.Log().Info("帮飞配额: %v", remaining)
+
+	// 获取传说列表
+	resp, err := _requestEpicList(playerInfo)
+	if err != nil {
+		playerInfo.Log().Error("获取传说列表失败: %v", err)
+		helper.Event("ERROR", "获取传说列表失败: %v", err)
+		return _incrRound(playerInfo)
+	}
+	if _isUnauthorized(resp) {
+		resp.Body.Close()
+		if _recordUnauthorized(helper, resp.Status) {
+			return 0
+		}
+		return _incrRound(playerInfo)
+	}
+	helper.resetUnauthorized()
+
+	invitationEpics := _checkInvitationEpics(resp, playerInfo)
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	if len(invitationEpics) == 0 {
+		playerInfo.Log().Notice("当前没有邀请的传说, 等待下一次刷新")
+		return _incrRound(playerInfo)
+	}
+
+	// 如果有传说, 随便获取一个传说列表, 找到邀请的传说
+	epic := invitationEpics[0]
+	resp, err = _requestFleetList(epic.Id, playerInfo)
+	if err != nil {
+		playerInfo.Log().Error("获取舰队列表失败: %v", err)
+		helper.Event("ERROR", "获取舰队列表失败: %v", err)
+		return _incrRound(playerInfo)
+	}
+
+	fleet := _getInvitationFleet(resp, playerInfo)
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	if fleet == nil {
+		playerInfo.Log().Notice("当前没有邀请的舰队, 等待下次刷新")
+		return _incrRound(playerInfo)
+	}
+	fleet.EpicId = epic.Id
+
+	appliedOk := _applyInvitedFleet(playerInfo, fleet)
+	if appliedOk == false {
+		_fleetLog(playerInfo, fleet).Notice("加入舰队[%v:%v]失败, 等待下次刷新", fleet.Name, fleet.Id)
+		helper.Event("ERROR", "加入舰队[%v:%v]失败", fleet.Name, fleet.Id)
+		return _incrRound(playerInfo)
+	}
+
+	// BI: 更新加入同一舰队的数量
+	_incrJoinedTimes(fleet.Id, playerInfo)
+	metrics.FleetsJoined.Inc(playerInfo.Name)
+	record := &history.Record{
+		Account:   playerInfo.Name,
+		PlayerId:  playerInfo.PlayerId(),
+		EpicId:    epic.Id,
+		EpicName:  epic.Name,
+		FleetId:   fleet.Id,
+		FleetName: fleet.Name,
+		Captain:   fleet.Captain.Name,
+		JoinedAt:  time.Now(),
+	}
+	tracker.RecordJoin(record.JoinedAt)
+	leaveAt := record.JoinedAt.Add(waitDuration)
+	helper.joinFleet(fleet, record, leaveAt)
+	helper.Event("NOTICE", "加入舰队[%v:%v] by (%v), 将在%v离开", fleet.Name, fleet.Id, fleet.Captain.Name, leaveAt.Format("15:04:05"))
+
+	_leaveHistoryComment(playerInfo, fleet, record, COMMENT_JOINED)
+
+	// 5分钟之后自动退出, 退出之前不再查看邀请
+	_scheduleLeave(helper, leaveAt)
+
+	return 0
+}
+
+func _scheduleInvitation(helper *Helper, at time.Time) {
+	sched.Schedule(helper.PlayerInfo.Name, TASK_INVITATION, at, func() time.Duration {
+		return _checkInvitation(helper)
+	})
+}
+
+func _scheduleLeave(helper *Helper, at time.Time) {
+	sched.Schedule(helper.PlayerInfo.Name, TASK_LEAVE, at, func() time.Duration {
+		return _leaveInvitedFleet(helper)
+	})
+}
+
+// 留言之后离开舰队, 然后重新开始查看邀请
+func _leaveInvitedFleet(helper *Helper) time.Duration {
+	playerInfo, _, tracker := helper.snapshot()
+
+	fleet, record := helper.takeFleet()
+	if fleet == nil {
+		// 没有记录在案的舰队, 比如通过管理接口强制离队
+		_leaveCurrentEpicIfExists(playerInfo)
+		return 0
+	}
+
+	// 重试离队的时候不再留言
+	if record.LeaveAttempts == 0 {
+		_leaveHistoryComment(playerInfo, fleet, record, COMMENT_LEAVE)
+
+		if leaveComment := _getRandomComment(); leaveComment != "" {
+			_leaveHistoryComment(playerInfo, fleet, record, leaveComment)
+		}
+	}
+
+	record.LeaveAttempts += 1
+	record.LeaveOk = _doLeaveFleet(playerInfo, fleet, record.LeaveAttempts)
+	if !record.LeaveOk && record.LeaveAttempts < MaxLeaveAttempts {
+		// 不在Worker里等待, 把舰队放回去, 过一会儿重新安排离队任务
+		metrics.LeaveRetries.Inc(playerInfo.Name)
+		retryAt := time.Now().Add(LeaveRetryDuration)
+		helper.joinFleet(fleet, record, retryAt)
+		_scheduleLeave(helper, retryAt)
+		return 0
+	}
+	if !record.LeaveOk {
+		metrics.LeaveFailures.Inc(playerInfo.Name)
+	}
+	record.LeftAt = time.Now()
+	helper.SetState(metrics.STATE_IDLE)
+	if record.LeaveOk {
+		helper.Event("NOTICE", "离开舰队[%v:%v]", fleet.Name, fleet.Id)
+	} else {
+		helper.Event("ERROR", "尝试%v次之后仍然没有离开舰队[%v:%v]", record.LeaveAttempts, fleet.Name, fleet.Id)
+		notifier.Send(notify.EVENT_LEAVE_FAILED, playerInfo.Name, playerInfo.PlayerId(),
+			"尝试%v次之后仍然没有离开舰队[%v:%v] by (%v)", record.LeaveAttempts, fleet.Name, fleet.Id, fleet.Captain.Name)
+	}
+	tracker.RecordDuration(record.JoinedAt, record.LeftAt.Sub(record.JoinedAt))
+	if err := history.Save(redis, record); err != nil {
+		_fleetLog(playerInfo, fleet).Error("保存帮飞记录失败: %v", err)
+	}
+
+	if helper.IsStopping() {
+		_removeHelper(helper)
+		return 0
+	}
+	_scheduleInvitation(helper, time.Now().Add(_incrRound(playerInfo)))
+
+	return 0
+}
+
+// 留言成功的话记到帮飞记录里
+func _leaveHistoryComment(playerInfo api.PlayerInfo, fleet *Fleet, record *history.Record, comment string) {
+	if _leaveComment(playerInfo, fleet, comment) {
+		record.Comments = append(record.Comments, comment)
+	}
+}
+
+func _scheduleFriend(helper *Helper, at time.Time) {
+	sched.Schedule(helper.PlayerInfo.Name, TASK_FRIEND, at, func() time.Duration {
+		return _checkFriendTask(helper)
+	})
+}
+
+func _checkFriendTask(helper *Helper) time.Duration {
+	if helper.IsSuspended() {
+		return 0
+	}
+
+	playerInfo, _, _ := helper.snapshot()
+	statusCode, _ := api.CheckFriendInvitation(playerInfo)
+	if _isUnauthorizedStatus(statusCode) {
+		if _recordUnauthorized(helper, fmt.Sprintf("%v %v", statusCode, http.StatusText(statusCode))) {
+			return 0
+		}
+	} else if statusCode == http.StatusOK {
+		helper.resetUnauthorized()
+	}
+	return FriendDuration
+}
+
+// 帮飞和好友申请共用一个计数, 连续被拒绝MaxUnauthorized次就停用账号; 停用了返回true
+func _recordUnauthorized(helper *Helper, status string) bool {
+	playerInfo, _, _ := helper.snapshot()
+	times := helper.recordUnauthorized()
+	playerInfo.Log().Error("AuthToken或者Cookie已经失效(连续%v次): %v", times, status)
+	helper.Event("ERROR", "AuthToken或者Cookie已经失效: %v", status)
+	if times == 1 {
+		notifier.Send(notify.EVENT_AUTH_REJECTED, playerInfo.Name, playerInfo.PlayerId(), "AuthToken或者Cookie已经失效: %v", status)
+	}
+	if times >= MaxUnauthorized {
+		_suspendHelper(helper, fmt.Sprintf("连续%v次返回%v", times, status))
+		return true
+	}
+	return false
+}
+
+// 凭证失效之后停用账号, 不再请求接口, 等配置文件里的凭证更新之后再恢复
+func _suspendHelper(helper *Helper, reason string) {
+	helper.mu.Lock()
+	helper.suspended = true
+	playerInfo := helper.PlayerInfo
+	helper.mu.Unlock()
+	helper.SetState(metrics.STATE_SUSPENDED)
+	sched.Cancel(playerInfo.Name, TASK_FRIEND)
+
+	suspension := status.Suspension{
+		Account:     playerInfo.Name,
+		PlayerId:    playerInfo.PlayerId(),
+		Reason:      reason,
+		SuspendedAt: time.Now(),
+		Fingerprint: playerInfo.Fingerprint(),
+	}
+	if err := status.SaveSuspension(redis, suspension); err != nil {
+		playerInfo.Log().Error("保存停用记录失败: %v", err)
+	}
+
+	playerInfo.Log().Error("凭证失效(%v), 已经停用, 更新配置文件之后自动恢复", reason)
+	helper.Event("ERROR", "凭证失效(%v), 已经停用, 更新配置文件之后自动恢复", reason)
+	notifier.Send(notify.EVENT_ACCOUNT_SUSPENDED, playerInfo.Name, playerInfo.PlayerId(),
+		"凭证失效(%v), 已经停用, 更新配置文件里的AuthToken和Cookie之后自动恢复", reason)
+}
+
+// 换上新的凭证, 重新开始帮飞和查看好友申请
+func _resumeHelper(helper *Helper, playerInfo api.PlayerInfo) {
+	helper.mu.Lock()
+	helper.PlayerInfo.AuthToken = playerInfo.AuthToken
+	helper.PlayerInfo.Cookie = playerInfo.Cookie
+	helper.suspended = false
+	helper.unauthorized = 0
+	paused := helper.paused
+	epicHelper := helper.PlayerInfo.EpicHelper
+	helper.mu.Unlock()
+
+	if err := status.RemoveSuspension(redis, playerInfo.PlayerId()); err != nil {
+		playerInfo.Log().Error("删除停用记录失败: %v", err)
+	}
+
+	now := time.Now()
+	if epicHelper && !paused {
+		helper.SetState(metrics.STATE_IDLE)
+		_scheduleInvitation(helper, now)
+	} else if paused {
+		helper.SetState(metrics.STATE_PAUSED)
+	} else {
+		helper.SetState(metrics.STATE_IDLE)
+	}
+	_scheduleFriend(helper, now)
+
+	playerInfo.Log().Notice("配置文件里的凭证已经更新, 恢复账号")
+	helper.Event("NOTICE", "配置文件里的凭证已经更新, 恢复账号")
+}
+
+// 配置文件修改或者收到SIGHUP之后重新加载账号
+type configWatcher struct {
+	mu           sync.Mutex
+	path         string
+	lastModified time.Time
+}
+
+func newConfigWatcher(path string) *configWatcher {
+	watcher := &configWatcher{path: path, lastModified: time.Now()}
+	if info, err := os.Stat(path); err == nil {
+		watcher.lastModified = info.ModTime()
+	}
+
+	return watcher
+}
+
+// 定时检查配置文件的修改时间
+func (this *configWatcher) check() time.Duration {
+	info, err := os.Stat(this.path)
+	if err == nil && info.ModTime().After(this.lastModified) {
+		this.reload()
+	}
+
+	return ConfigCheckDuration
+}
+
+// 配置有问题的话保留原来的配置
+func (this *configWatcher) reload() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if info, err := os.Stat(this.path); err == nil {
+		this.lastModified = info.ModTime()
+	}
+
+	newConfig, err := config.Load(this.path)
+	if err != nil {
+		log.Error("配置文件有问题, 继续使用原来的配置: %v", err)
+		return
+	}
+	schedules, err := _validateConfig(newConfig)
+	if err != nil {
+		log.Error("配置文件有问题, 继续使用原来的配置: %v", err)
+		return
+	}
+
+	now := time.Now()
+	seen := make(map[int]bool)
+	for _, info := range newConfig.PlayerInfo {
+		playerId := info.PlayerId()
+		seen[playerId] = true
+
+		helper, err := _findHelper(playerId)
+		if err != nil {
+			result := _checkAccount(info)
+			if result.Status != CHECK_OK && !forceStart {
+				info.Log().Error("新的账号检查没有通过(%v: %v), 不会启动", result.Status, result.Detail)
+				continue
+			}
+			if _, err := _startHelper(info, now); err != nil {
+				info.Log().Error("启动新的账号失败: %v", err)
+				continue
+			}
+			info.Log().Notice("配置文件里增加了账号, 已经启动")
+			continue
+		}
+
+		if helper.IsStopping() {
+			continue
+		}
+		_updateHelper(helper, info, schedules[playerId])
+	}
+
+	for _, helper := range _allHelpers() {
+		playerInfo, _, _ := helper.snapshot()
+		if !seen[playerInfo.PlayerId()] && !helper.IsStopping() {
+			playerInfo.Log().Notice("账号已经从配置文件里删除")
+			_stopHelper(helper)
+		}
+	}
+
+	log.Notice("重新加载配置文件[%v]完成", this.path)
+}
+
+type accountSchedule struct {
+	calendar *scheduler.Calendar
+	quota    *quota.Tracker
+}
+
+// 整个配置都没有问题才会生效
+func _validateConfig(newConfig config.Config) (map[int]accountSchedule, error) {
+	schedules := make(map[int]accountSchedule)
+	names := make(map[string]bool)
+	for _, info := range newConfig.PlayerInfo {
+		playerId := info.PlayerId()
+		if playerId <= 0 {
+			return nil, fmt.Errorf("「%v」的AuthToken里没有PlayerId", info.Name)
+		}
+		if _, ok := schedules[playerId]; ok {
+			return nil, fmt.Errorf("PlayerId[%v]重复了", playerId)
+		}
+		if names[info.Name] {
+			return nil, fmt.Errorf("账号名称「%v」重复了", info.Name)
+		}
+		names[info.Name] = true
+
+		calendar, tracker, err := _newSchedule(info)
+		if err != nil {
+			return nil, fmt.Errorf("「%v」的配置有问题: %v", info.Name, err)
+		}
+		schedules[playerId] = accountSchedule{calendar: calendar, quota: tracker}
+	}
+
+	return schedules, nil
+}
+
+// 直接修改运行中的账号, 任务下一次执行的时候生效
+func _updateHelper(helper *Helper, info api.PlayerInfo, schedule accountSchedule) {
+	old, _, _ := helper.snapshot()
+	if info.Name != old.Name {
+		old.Log().Warning("账号名称改为「%v」需要重启才能生效", info.Name)
+		info.Name = old.Name
+	}
+	if reflect.DeepEqual(old, info) {
+		return
+	}
+
+	credentialsChanged := info.Fingerprint() != old.Fingerprint()
+	helper.mu.Lock()
+	// 凭证在恢复停用账号的时候更新
+	if !helper.suspended {
+		helper.PlayerInfo.AuthToken = info.AuthToken
+		helper.PlayerInfo.Cookie = info.Cookie
+	}
+	helper.PlayerInfo.ClientVersion = info.ClientVersion
+	helper.PlayerInfo.Platform = info.Platform
+	helper.PlayerInfo.Locale = info.Locale
+	helper.PlayerInfo.ConvertedEnergy = info.ConvertedEnergy
+	helper.PlayerInfo.EpicHelper = info.EpicHelper
+	helper.PlayerInfo.TimeZone = info.TimeZone
+	helper.PlayerInfo.ActiveWindows = info.ActiveWindows
+	helper.PlayerInfo.BlackoutDates = info.BlackoutDates
+	helper.PlayerInfo.MaxFleetsPerHour = info.MaxFleetsPerHour
+	helper.PlayerInfo.MaxFleetsPerDay = info.MaxFleetsPerDay
+	helper.PlayerInfo.MaxFleetMinutesPerDay = info.MaxFleetMinutesPerDay
+	helper.PlayerInfo.QuotaResetAt = info.QuotaResetAt
+	helper.Calendar = schedule.calendar
+	helper.Quota = schedule.quota
+	suspended := helper.suspended
+	inFleet := helper.fleet != nil
+	paused := helper.paused
+	helper.mu.Unlock()
+
+	info.Log().Notice("配置已经更新")
+	helper.Event("NOTICE", "配置已经更新")
+
+	if suspended {
+		if credentialsChanged {
+			_resumeHelper(helper, info)
+		}
+		return
+	}
+
+	// 帮飞开关的变化, 在舰队里的话离队之后再处理
+	if info.EpicHelper && !old.EpicHelper && !paused && !inFleet {
+		_scheduleInvitation(helper, time.Now())
+	} else if !info.EpicHelper && old.EpicHelper {
+		sched.Cancel(info.Name, TASK_INVITATION)
+	}
+}
+
+// 把账号状态写到Redis, 其他进程的状态面板可以读取
+func _saveStatus() time.Duration {
+	if err := status.SaveAccounts(redis, helperController{}.Accounts()); err != nil {
+		log.Error("保存账号状态失败: %v", err)
+	}
+
+	return StatusDuration
+}
+
+// 打印接下来要执行的任务
+func _logSchedule() time.Duration {
+	for _, task := range sched.Pending() {
+		if task.Running {
+			logger.New("epic", logger.Fields{Account: task.Account}).Info("任务[%v]正在执行", task.Name)
+		} else {
+			logger.New("epic", logger.Fields{Account: task.Account}).Info("任务[%v]将在%v之后执行", task.Name, task.NextRun.Sub(time.Now()).Truncate(time.Second))
+		}
+	}
+
+	return ScheduleLogDuration
+}
+
+func _getRandomComment() string {
+	commentCountingKey := "epic:comments:counting"
+	leaveComment := ""
+	if countingMap, err := redis.HGetAllMap(commentCountingKey).Result(); err == nil {
+		maxCount := 0
+		dataMap := make(map[string]int, 0)
+		for comment, countStr := range countingMap {
+			count, _ := strconv.Atoi(countStr)
+			if count > maxCount {
+				maxCount = count
+			}
+			dataMap[comment] = count
+		}
+		for comment, count := range dataMap {
+			dataMap[comment] = int(math.Pow(float64(maxCount-count), 2)) + 1 // 加1是为了防止大家都平分的情况下导致没有记录选择出来
+		}
+		leaveComment = utils.GetRandomDataByWeight(dataMap)
+		// leaveComment = leaveComments.List[rand.New(rand.NewSource(time.Now().UnixNano())).Intn(len(leaveComments.List))]
+
+		redis.HIncrBy(commentCountingKey, leaveComment, 1)
+	}
+
+	return leaveComment
+}
+
+func _leaveCurrentEpicIfExists(playerInfo api.PlayerInfo) bool {
+	client := api.HttpClient
+	v := url.Values{}
+	v.Add("locale", playerInfo.Locale)
+	v.Add("platform", playerInfo.Platform)
+	v.Add("auth_token", playerInfo.AuthToken)
+	v.Add("client_version", playerInfo.ClientVersion)
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/fleets/current?%v", v.Encode())
+	req, err := utils.GenerateWalkrRequest(host, "GET", playerInfo.Cookie, nil)
+	if err != nil {
+		return false
+	}
+
+	if resp, err := client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			playerInfo.Log().Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record CurrentEpicResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			playerInfo.Log().Error("解析当前舰队信息失败: %v", err)
+			return false
+		}
+
+		if record.Success == true && record.FleetId != 0 {
+			playerInfo.Log().WithFleet(0, record.FleetId).Notice("当前有执行中的舰队['%v':%v], 即将离开舰队", record.Name, record.FleetId)
+
+			// 循环开始之前有舰队存在，退出当前舰队, 失败的话下一轮再试
+			if !_doLeaveFleet(playerInfo, &Fleet{Id: record.FleetId, Name: record.Name}, 1) {
+				metrics.LeaveRetries.Inc(playerInfo.Name)
+			}
+		} else {
+			playerInfo.Log().Debug("当前没有执行中的舰队, 即将查看邀请列表")
+		}
+
+		return true
+	} else {
+		playerInfo.Log().Error("获取当前舰队信息失败: %v", err)
+		return false
+	}
+
+}
+
+func _requestEpicList(playerInfo api.PlayerInfo) (*http.Response, error) {
+	client := api.HttpClient
+	v := url.Values{}
+	v.Add("locale", playerInfo.Locale)
+	v.Add("platform", playerInfo.Platform)
+	v.Add("auth_token", playerInfo.AuthToken)
+	v.Add("client_version", playerInfo.ClientVersion)
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/epics?%v", v.Encode())
+	req, err := utils.GenerateWalkrRequest(host, "GET", playerInfo.Cookie, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+
+}
+
+func _requestFleetList(invitationEpicId int, playerInfo api.PlayerInfo) (*http.Response, error) {
+	client := api.HttpClient
+	v := url.Values{}
+	v.Add("locale", playerInfo.Locale)
+	v.Add("platform", playerInfo.Platform)
+	v.Add("auth_token", playerInfo.AuthToken)
+	v.Add("client_version", playerInfo.ClientVersion)
+	v.Add("country_code", "US")
+	v.Add("epic_id", fmt.Sprintf("%v", invitationEpicId))
+	v.Add("limit", "30")
+	v.Add("name", "")
+	v.Add("offset", "0")
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/fleets?%v", v.Encode())
+	req, err := utils.GenerateWalkrRequest(host, "GET", playerInfo.Cookie, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+}
+
+func _applyInvitedFleet(playerInfo api.PlayerInfo, fleet *Fleet) bool {
+	client := api.HttpClient
+	b, err := json.Marshal(playerInfo)
+	if err != nil {
+		_fleetLog(playerInfo, fleet).Error("Json Marshal error for %v", err)
+		return false
+	}
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/fleets/%v/apply", fleet.Id)
+	req, err := utils.GenerateWalkrRequest(host, "POST", playerInfo.Cookie, bytes.NewBuffer([]byte(b)))
+	if err != nil {
+		return false
+	}
+
+	if resp, err := client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			_fleetLog(playerInfo, fleet).Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record api.BoolResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			_fleetLog(playerInfo, fleet).Error("加入舰队失败: %v", err)
+			return false
+		}
+
+		_fleetLog(playerInfo, fleet).Notice("已经加入舰队[%v:%v], 等待起飞", fleet.Name, fleet.Id)
+
+		return record.Success
+	} else {
+		_fleetLog(playerInfo, fleet).Error("请求加入舰队失败: %v", err)
+
+	}
+
+	return false
+}
+
+func _leaveComment(playerInfo api.PlayerInfo, fleet *Fleet, comment string) bool {
+	client := api.HttpClient
+
+	commentRequestJson := CommentRequest{
+		AuthToken:     playerInfo.AuthToken,
+		ClientVersion: playerInfo.ClientVersion,
+		Platform:      playerInfo.Platform,
+		Locale:        playerInfo.Locale,
+		Text:          comment,
+	}
+	b, err := json.Marshal(commentRequestJson)
+	if err != nil {
+		_fleetLog(playerInfo, fleet).Error("Json Marshal error for %v", err)
+		return false
+	}
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/fleets/%v/comment", fleet.Id)
+	req, err := utils.GenerateWalkrRequest(host, "POST", playerInfo.Cookie, bytes.NewBuffer([]byte(b)))
+	if err != nil {
+		_fleetLog(playerInfo, fleet).Error("请求留言失败 %v", err)
+		return false
+	}
+
+	if resp, err := client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			_fleetLog(playerInfo, fleet).Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record api.BoolResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			_fleetLog(playerInfo, fleet).Error("留言失败: %v", err)
+			return false
+		}
+
+		_fleetLog(playerInfo, fleet).Notice("已经留言(%v)", comment)
+		if record.Success {
+			metrics.CommentsPosted.Inc(playerInfo.Name)
+		}
+
+		return record.Success
+	} else {
+		_fleetLog(playerInfo, fleet).Error("请求用户留言失败: %v", err)
+
+	}
+
+	return false
+}
+
+// 尝试离开一次舰队, attempt是第几次尝试; 失败之后什么时候重试由调用的地方决定
+func _doLeaveFleet(playerInfo api.PlayerInfo, fleet *Fleet, attempt int) bool {
+	if _leaveFleet(playerInfo, fleet) {
+		metrics.FleetsLeft.Inc(playerInfo.Name)
+		return true
+	}
+	_fleetLog(playerInfo, fleet).Error("尝试第%v次离开舰队失败，稍后尝试", attempt)
+	return false
+}
+
+func _leaveFleet(playerInfo api.PlayerInfo, fleet *Fleet) bool {
+	client := api.HttpClient
+
+	b, err := json.Marshal(playerInfo)
+	if err != nil {
+		_fleetLog(playerInfo, fleet).Error("Json Marshal error for %v", err)
+		return false
+	}
+
+	host := fmt.Sprintf("https://universe.walkrgame.com/api/v1/fleets/%v/leave", fleet.Id)
+	req, err := utils.GenerateWalkrRequest(host, "POST", playerInfo.Cookie, bytes.NewBuffer([]byte(b)))
+	if err != nil {
+		return false
+	}
+
+	if resp, err := client.Do(req); err == nil {
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			_fleetLog(playerInfo, fleet).Error("读取返回数据失败: %v", err)
+			return false
+		}
+
+		var record api.BoolResponse
+		if err := json.Unmarshal([]byte(body), &record); err != nil {
+			_fleetLog(playerInfo, fleet).Error("离开舰队失败: %v", err)
+			return false
+		}
+
+		_fleetLog(playerInfo, fleet).Notice("退出舰队[%v:%v]成功", fleet.Name, fleet.Id)
+
+		return record.Success
+	} else {
+		_fleetLog(playerInfo, fleet).Error("请求离开舰队失败: %v", err)
+
+	}
+
+	return false
+}
+
+func _checkInvitationCount(resp *http.Response, playerInfo api.PlayerInfo) bool {
+	isInvitation := false
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		playerInfo.Log().Error("读取返回数据失败: %v", err)
+		return isInvitation
+	}
+
+	var records EpicListResponse
+	if err := json.Unmarshal([]byte(body), &records); err != nil {
+		playerInfo.Log().Error("解析传说列表数据失败: %v", err)
+		return isInvitation
+	}
+
+	for _, epic := range records.Epics {
+		playerInfo.Log().Debug("传说[%v], 邀请数量[%v]", epic.Name, epic.InvitationCounts)
+
+		if epic.InvitationCounts > 0 {
+			isInvitation = true
+		}
+	}
+
+	return isInvitation
+}
+
+func _checkInvitationEpics(resp *http.Response, playerInfo api.PlayerInfo) []Epic {
+	var invitationEpics []Epic
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		playerInfo.Log().Error("读取返回数据失败: %v", err)
+		return invitationEpics
+	}
+
+	var records EpicListResponse
+	if err := json.Unmarshal([]byte(body), &records); err != nil {
+		playerInfo.Log().Error("解析传说列表数据失败: %v", err)
+		return invitationEpics
+	}
+
+	for _, epic := range records.Epics {
+		playerInfo.Log().Debug("传说[%v], 邀请数量[%v]", epic.Name, epic.InvitationCounts)
+
+		if epic.InvitationCounts > 0 {
+			invitationEpics = append(invitationEpics, epic)
+		}
+	}
+
+	return invitationEpics
+}
+
+func _getInvitationFleet(resp *http.Response, playerInfo api.PlayerInfo) *Fleet {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		playerInfo.Log().Error("读取返回数据失败: %v", err)
+		return nil
+	}
+
+	var records FleetListResponse
+	if err := json.Unmarshal([]byte(body), &records); err != nil {
+		playerInfo.Log().Error("解析传说列表数据失败: %v", err)
+		return nil
+	}
+
+	var fleets Fleets
+	for _, fleet := range records.Fleets {
+		playerInfo.Log().Debug("%+v", fleet)
+		if fleet.IsInvited == true {
+			if _isBlacklisted(fleet.Id, playerInfo) {
+				_fleetLog(playerInfo, &fleet).Debug("舰队[%v:%v] by (%v): 在黑名单里, 不帮飞", fleet.Name, fleet.Id, fleet.Captain.Name)
+				continue
+			}
+			fleet.Quality = _getJoinedTimes(fleet.Id, playerInfo)
+
+			if fleet.Quality <= MaxJoinedTimes {
+				fleets = append(fleets, fleet)
+
+			} else {
+				_fleetLog(playerInfo, &fleet).Error("舰队[%v:%v] by (%v): 已经到达自动帮飞次数上限, 加入黑名单", fleet.Name, fleet.Id, fleet.Captain.Name)
+				if _addToBlacklist(fleet.Id, playerInfo) {
+					notifier.Send(notify.EVENT_FLEET_BLACKLISTED, playerInfo.Name, playerInfo.PlayerId(),
+						"舰队[%v:%v] by (%v)邀请超过%v次, 已经加入黑名单", fleet.Name, fleet.Id, fleet.Captain.Name, MaxJoinedTimes)
+				}
+
+			}
+
+		}
+	}
+
+	if len(fleets) > 0 {
+		// 加入次数少的队伍优先进入, 防止恶意邀请阻塞进程
+		sort.Sort(fleets)
+
+		firstFleet := &fleets[0]
+		_fleetLog(playerInfo, firstFleet).Notice("舰队[%v:%v] by (%v): 正在邀请, 优先度(%v)", firstFleet.Name, firstFleet.Id, firstFleet.Captain.Name, firstFleet.Quality)
+
+		return firstFleet
+	}
+
+	return nil
+}
+
+// 日志里带上舰队和传说
+func _fleetLog(playerInfo api.PlayerInfo, fleet *Fleet) *logger.Logger {
+	return playerInfo.Log().WithFleet(fleet.EpicId, fleet.Id)
+}
+
+// BI相关
+func _getRound(playerInfo api.PlayerInfo) int {
+	roundKey := "epic:round"
+
+	currentRound, err := strconv.Atoi(redis.HGet(roundKey, strconv.Itoa(playerInfo.PlayerId())).Val())
+	if err != nil || currentRound <= 0 {
+		currentRound = 1
+	}
+
+	return currentRound
+
+}
+func _incrRound(playerInfo api.PlayerInfo) time.Duration {
+	roundKey := "epic:round"
+	redis.HIncrBy(roundKey, strconv.Itoa(playerInfo.PlayerId()), 1)
+	metrics.Rounds.Inc(playerInfo.Name)
+
+	return RoundDuration
+}
+
+func _getJoinedTimes(fleetId int, playerInfo api.PlayerInfo) int {
+	times, err := strconv.Atoi(redis.HGet(fmt.Sprintf("epic:%v:fleet:times", playerInfo.PlayerId()), fmt.Sprintf("%v", fleetId)).Val())
+	if err != nil || times <= 0 {
+		times = 0
+	}
+
+	return times
+}
+
+func _incrJoinedTimes(fleetId int, playerInfo api.PlayerInfo) {
+	redis.HIncrBy(fmt.Sprintf("epic:%v:fleet:times", playerInfo.PlayerId()), fmt.Sprintf("%v", fleetId), 1)
+}
+
+// 第一次加入黑名单的时候返回true
+func _addToBlacklist(fleetId int, playerInfo api.PlayerInfo) bool {
+	added, err := redis.SAdd(fmt.Sprintf("epic:%v:fleet:blacklist", playerInfo.PlayerId()), strconv.Itoa(fleetId)).Result()
+	return err == nil && added > 0
+}
+
+// 用walkr maint fleet-times -reset -blacklist清空黑名单之后才会再帮飞
+func _isBlacklisted(fleetId int, playerInfo api.PlayerInfo) bool {
+	return redis.SIsMember(fmt.Sprintf("epic:%v:fleet:blacklist", playerInfo.PlayerId()), strconv.Itoa(fleetId)).Val()
+}
+
+func _isUnauthorized(resp *http.Response) bool {
+	return _isUnauthorizedStatus(resp.StatusCode)
+}
+
+func _isUnauthorizedStatus(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+func _saveCommentsToRedis() {
+	commentCountingKey := "epic:comments:counting"
+	for _, comment := range leaveComments.List {
+		redis.HIncrBy(commentCountingKey, comment, 0)
+	}
+}
+
+// 帮飞: walkr epic [-w 5] [-metrics :9100] [-admin 127.0.0.1:9898] [-force]
+func runEpic(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "history":
+			_runHistory(args[1:])
+			return
+		case "status":
+			_runStatus(args[1:])
+			return
+		case "vault":
+			_runVault(args[1:])
+			return
+		case "check":
+			_runCheck(args[1:])
+			return
+		}
+	}
+
+	flags := _newFlagSet("epic", "[-w 5] [-metrics :9100] [-admin 127.0.0.1:9898] [-admin-token xxx] [-force]\n"+
+		"       walkr epic history|status|check|vault [参数]")
+	workers := flags.Int("w", 5, "同时执行任务的数量")
+	metricsAddr := flags.String("metrics", "", "Prometheus指标的监听地址, 比如 ':9100', 为空则不开启")
+	adminAddr := flags.String("admin", "", "管理接口的监听地址, 只能是本机地址, 比如 '127.0.0.1:9898', 为空则不开启")
+	adminToken := flags.String("admin-token", os.Getenv("WALKR_ADMIN_TOKEN"), "管理接口的Token, 默认读取环境变量WALKR_ADMIN_TOKEN")
+	flags.BoolVar(&forceStart, "force", false, "账号检查没有通过也照常启动")
+	flags.Parse(args)
+
+	var err error
+	if conf, err = _loadConfig(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
+	if _, err := toml.DecodeFile("comments.toml", &leaveComments); err != nil {
+		log.Error("解析留言列表有问题: %v", err)
+		return
+	}
+	_saveCommentsToRedis()
+
+	if conf.Webhook.URL != "" {
+		n, err := notify.New(conf.Webhook)
+		if err != nil {
+			log.Error("Webhook配置有问题: %v", err)
+			return
+		}
+		notifier = n
+	}
+
+	// for i := 0; i < 100000; i++ {
+	// 	log.Debug("Comment: %v", _getRandomComment())
+
+	// }
+
+	if _, err := _validateConfig(conf); err != nil {
+		log.Error("配置文件有问题: %v", err)
+		return
+	}
+
+	// 启动之前先检查一遍账号, 有问题的账号不启动
+	results := _checkAccounts(conf.PlayerInfo)
+	_writeCheckResults(os.Stderr, results)
+	playerInfos := []api.PlayerInfo{}
+	for _, result := range results {
+		if result.Status != CHECK_OK && !forceStart {
+			result.PlayerInfo.Log().Error("账号检查没有通过(%v), 不会启动, 可以用-force强制启动", result.Status)
+			continue
+		}
+		playerInfos = append(playerInfos, result.PlayerInfo)
+	}
+
+	epicHelpers := 0
+	for _, info := range playerInfos {
+		if info.EpicHelper {
+			epicHelpers += 1
+		}
+	}
+	if epicHelpers == 0 {
+		log.Error("没有配置帮飞号信息")
+		return
+	}
+
+	// 帮飞和好友申请都交给同一个调度器
+	sched = scheduler.New(*workers)
+	sched.OnPanic = func(task scheduler.Task, stack string) {
+		playerId := 0
+		for _, helper := range _allHelpers() {
+			if playerInfo, _, _ := helper.snapshot(); playerInfo.Name == task.Account {
+				playerId = playerInfo.PlayerId()
+			}
+		}
+		notifier.Send(notify.EVENT_WORKER_CRASHED, task.Account, playerId, "任务[%v]挂了: %v", task.Name, stack)
+	}
+	now := time.Now()
+	for _, info := range playerInfos {
+		if _, err := _startHelper(info, now); err != nil {
+			info.Log().Error("配置有问题: %v", err)
+			return
+		}
+	}
+	sched.Schedule("", TASK_SCHEDULE, now.Add(ScheduleLogDuration), _logSchedule)
+	watcher := newConfigWatcher(*configFile)
+	sched.Schedule("", TASK_CONFIG, now.Add(ConfigCheckDuration), watcher.check)
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			log.Notice("收到SIGHUP, 重新加载配置文件")
+			sched.Schedule("", TASK_RELOAD, time.Now(), func() time.Duration {
+				watcher.reload()
+				return 0
+			})
+		}
+	}()
+	redis.Del(status.StatusKey)
+	sched.Schedule("", TASK_STATUS, now, _saveStatus)
+
+	if *adminAddr != "" {
+		go func() {
+			log.Notice("管理接口监听在 http://%v", *adminAddr)
+			if err := admin.Listen(*adminAddr, *adminToken, helperController{}); err != nil {
+				log.Error("管理接口监听失败: %v", err)
+			}
+		}()
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			log.Notice("Prometheus指标监听在 %v/metrics", *metricsAddr)
+			if err := metrics.Listen(*metricsAddr); err != nil {
+				log.Error("Prometheus指标监听失败: %v", err)
+			}
+		}()
+	}
+
+	sched.Run()
+
+}
+
+func _allHelpers() []*Helper {
+	helpersMu.RLock()
+	defer helpersMu.RUnlock()
+
+	all := make([]*Helper, 0, len(helpers))
+	for _, helper := range helpers {
+		all = append(all, helper)
+	}
+	return all
+}
+
+// 管理接口, 通过PlayerId找到账号
+type helperController struct{}
+
+func _findHelper(playerId int) (*Helper, error) {
+	helpersMu.RLock()
+	defer helpersMu.RUnlock()
+
+	helper, ok := helpers[playerId]
+	if !ok {
+		return nil, admin.ErrNotFound
+	}
+	return helper, nil
+}
+
+func (helperController) Accounts() []admin.Account {
+	nextTasks := make(map[string]scheduler.Task)
+	for _, task := range sched.Pending() {
+		if _, ok := nextTasks[task.Account]; !ok && !task.Running {
+			nextTasks[task.Account] = task
+		}
+	}
+
+	accounts := []admin.Account{}
+	for _, helper := range _allHelpers() {
+		playerInfo, _, tracker := helper.snapshot()
+		limits := tracker.Limits()
+		account := admin.Account{
+			Name:       playerInfo.Name,
+			PlayerId:   playerInfo.PlayerId(),
+			EpicHelper: playerInfo.EpicHelper,
+			Round:      _getRound(playerInfo),
+			UpdatedAt:  time.Now(),
+			Quota: admin.Quota{
+				MaxFleetsPerHour:      limits.FleetsPerHour,
+				MaxFleetsPerDay:       limits.FleetsPerDay,
+				MaxFleetMinutesPerDay: limits.FleetMinutesPerDay,
+			},
+		}
+
+		helper.mu.Lock()
+		account.State = helper.state
+		account.Paused = helper.paused
+		account.WaitDuration = helper.waitDuration.String()
+		account.LastError = helper.lastError
+		if helper.fleet != nil {
+			account.FleetId = helper.fleet.Id
+			account.FleetName = helper.fleet.Name
+			account.Captain = helper.fleet.Captain.Name
+			account.LeaveAt = helper.leaveAt
+		}
+		helper.mu.Unlock()
+
+		if task, ok := nextTasks[playerInfo.Name]; ok {
+			account.NextTask = task.Name
+			account.NextRun = task.NextRun
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	return accounts
+}
+
+func (helperController) Events(n int) ([]admin.Event, error) {
+	return status.LoadEvents(redis, n)
+}
+
+// 暂停之后当前的舰队会照常离开, 之后不再查看邀请
+func (helperController) Pause(playerId int) error {
+	helper, err := _findHelper(playerId)
+	if err != nil {
+		return err
+	}
+
+	helper.mu.Lock()
+	helper.paused = true
+	inFleet := helper.fleet != nil
+	playerInfo := helper.PlayerInfo
+	helper.mu.Unlock()
+	if !inFleet {
+		helper.SetState(metrics.STATE_PAUSED)
+	}
+	playerInfo.Log().Notice("通过管理接口暂停帮飞")
+	helper.Event("NOTICE", "通过管理接口暂停帮飞")
+
+	return nil
+}
+
+func (helperController) Resume(playerId int) error {
+	helper, err := _findHelper(playerId)
+	if err != nil {
+		return err
+	}
+	playerInfo, _, _ := helper.snapshot()
+	if !playerInfo.EpicHelper {
+		return fmt.Errorf("「%v」不是帮飞号", playerInfo.Name)
+	}
+	if helper.IsSuspended() {
+		return fmt.Errorf("「%v」的凭证已经失效, 需要先更新配置文件", playerInfo.Name)
+	}
+
+	helper.mu.Lock()
+	wasPaused := helper.paused
+	helper.paused = false
+	inFleet := helper.fleet != nil
+	helper.mu.Unlock()
+
+	// 在舰队里的话离队之后会自动查看邀请
+	if wasPaused && !inFleet {
+		helper.SetState(metrics.STATE_IDLE)
+		_scheduleInvitation(helper, time.Now())
+	}
+	playerInfo.Log().Notice("通过管理接口恢复帮飞")
+	helper.Event("NOTICE", "通过管理接口恢复帮飞")
+
+	return nil
+}
+
+func (helperController) Leave(playerId int) error {
+	helper, err := _findHelper(playerId)
+	if err != nil {
+		return err
+	}
+
+	_scheduleLeave(helper, time.Now())
+	playerInfo, _, _ := helper.snapshot()
+	playerInfo.Log().Notice("通过管理接口离开舰队")
+	helper.Event("NOTICE", "通过管理接口离开舰队")
+
+	return nil
+}
+
+func (helperController) CheckFriends(playerId int) error {
+	helper, err := _findHelper(playerId)
+	if err != nil {
+		return err
+	}
+
+	if helper.IsSuspended() {
+		return fmt.Errorf("「%v」的凭证已经失效, 需要先更新配置文件", helper.PlayerInfo.Name)
+	}
+	_scheduleFriend(helper, time.Now())
+
+	return nil
+}
+
+func (helperController) Update(playerId int, settings admin.Settings) error {
+	helper, err := _findHelper(playerId)
+	if err != nil {
+		return err
+	}
+
+	if settings.WaitDuration != "" {
+		waitDuration, err := time.ParseDuration(settings.WaitDuration)
+		if err != nil || waitDuration <= 0 {
+			return fmt.Errorf("等待时间[%v]有问题", settings.WaitDuration)
+		}
+		helper.mu.Lock()
+		helper.waitDuration = waitDuration
+		helper.mu.Unlock()
+	}
+
+	// 重新加载配置的时候会换掉Quota, 读改写都要在锁里
+	helper.mu.Lock()
+	playerInfo := helper.PlayerInfo
+	limits := helper.Quota.Limits()
+	if settings.MaxFleetsPerHour != nil {
+		limits.FleetsPerHour = *settings.MaxFleetsPerHour
+	}
+	if settings.MaxFleetsPerDay != nil {
+		limits.FleetsPerDay = *settings.MaxFleetsPerDay
+	}
+	if settings.MaxFleetMinutesPerDay != nil {
+		limits.FleetMinutesPerDay = *settings.MaxFleetMinutesPerDay
+	}
+	helper.Quota.SetLimits(limits)
+	helper.mu.Unlock()
+	playerInfo.Log().Notice("通过管理接口修改设置: 等待时间%v, 配额%+v", helper.WaitDuration(), limits)
+
+	return nil
+}
+
+// 账号检查的结果
+const (
+	CHECK_OK            = "OK"
+	CHECK_UNAUTHORIZED  = "unauthorized"
+	CHECK_NETWORK_ERROR = "network error"
+	CHECK_UNEXPECTED    = "unexpected"
+)
+
+type CheckResult struct {
+	PlayerInfo api.PlayerInfo
+	Status     string
+	Detail     string
+	Latency    time.Duration
+}
+
+// 请求传说列表, 这个接口不会改变任何状态
+func _checkAccount(playerInfo api.PlayerInfo) CheckResult {
+	result := CheckResult{PlayerInfo: playerInfo}
+
+	start := time.Now()
+	resp, err := _requestEpicList(playerInfo)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Status = CHECK_NETWORK_ERROR
+		result.Detail = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	if _isUnauthorized(resp) {
+		result.Status = CHECK_UNAUTHORIZED
+		result.Detail = resp.Status
+		return result
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.Status = CHECK_UNEXPECTED
+		result.Detail = resp.Status
+		return result
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		result.Status = CHECK_NETWORK_ERROR
+		result.Detail = err.Error()
+		return result
+	}
+	var records EpicListResponse
+	if err := json.Unmarshal(body, &records); err != nil {
+		result.Status = CHECK_UNEXPECTED
+		result.Detail = fmt.Sprintf("无法解析传说列表: %v", err)
+		return result
+	}
+
+	result.Status = CHECK_OK
+	result.Detail = fmt.Sprintf("%v个传说", len(records.Epics))
+	return result
+}
+
+// 同时检查所有账号, 结果按配置文件里的顺序排列
+func _checkAccounts(playerInfos []api.PlayerInfo) []CheckResult {
+	results := make([]CheckResult, len(playerInfos))
+
+	var wg sync.WaitGroup
+	for i, playerInfo := range playerInfos {
+		wg.Add(1)
+		go func(i int, playerInfo api.PlayerInfo) {
+			defer wg.Done()
+			results[i] = _checkAccount(playerInfo)
+		}(i, playerInfo)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func _writeCheckResults(w io.Writer, results []CheckResult) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "账号\tPlayerId\t帮飞号\t结果\t耗时\t说明")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", result.PlayerInfo.Name, result.PlayerInfo.PlayerId(), result.PlayerInfo.EpicHelper,
+			result.Status, result.Latency.Truncate(time.Millisecond), result.Detail)
+	}
+	tw.Flush()
+}
+
+// 检查账号的AuthToken和Cookie是否有效: walkr epic check
+func _runCheck(args []string) {
+	flags := _newFlagSet("epic check", "")
+	flags.Parse(args)
+
+	var err error
+	if conf, err = _loadConfig(); err != nil {
+		log.Error("%v", err)
+		os.Exit(1)
+	}
+
+	results := _checkAccounts(conf.PlayerInfo)
+	_writeCheckResults(os.Stdout, results)
+	for _, result := range results {
+		if result.Status != CHECK_OK {
+			os.Exit(1)
+		}
+	}
+}
+
+// 管理加密的凭证保险箱, 凭证从标准输入读取, 不会出现在命令行历史里, 也不会打印出来
+func _runVault(args []string) {
+	flags := _newFlagSet("epic vault", "[-path walkr.vault] [-key-file 密钥文件] add|rotate|remove|list [名称]")
+	path := flags.String("path", "", "保险箱文件, 为空则使用配置文件里[Vault]的Path, 默认walkr.vault")
+	keyFile := flags.String("key-file", "", "密钥文件, 为空则使用配置文件里[Vault]的KeyFile, 都没有的话读取环境变量"+secret.PassphraseEnv+"作为密码")
+	flags.Parse(args)
+
+	// 和读取配置文件时解析凭证用同一个保险箱
+	vaultConf, err := config.LoadVault(*configFile)
+	if err != nil {
+		log.Error("配置文件[%v]有问题: %v", *configFile, err)
+		os.Exit(1)
+	}
+	if *path != "" {
+		vaultConf.Path = *path
+	}
+	if *keyFile != "" {
+		vaultConf.KeyFile = *keyFile
+	}
+
+	action, name := flags.Arg(0), flags.Arg(1)
+	if action != "list" && name == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	vault, err := secret.Open(vaultConf, os.Getenv(secret.PassphraseEnv))
+	if err != nil {
+		log.Error("打开保险箱失败: %v", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "名称\t修改时间\t引用")
+		for _, item := range vault.List() {
+			fmt.Fprintf(tw, "%v\t%v\t%v%v\n", item.Name, item.UpdatedAt.Local().Format("2006-01-02 15:04:05"), secret.PREFIX_VAULT, item.Name)
+		}
+		tw.Flush()
+		return
+	case "add", "rotate":
+		if action == "add" && vault.Has(name) {
+			log.Error("[%v]已经存在, 需要修改的话用rotate", name)
+			os.Exit(1)
+		}
+		if action == "rotate" && !vault.Has(name) {
+			log.Error("[%v]不存在, 需要增加的话用add", name)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "输入[%v]的凭证: ", name)
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			log.Error("没有输入凭证: %v", err)
+			os.Exit(1)
+		}
+		vault.Set(name, value)
+	case "remove":
+		if err := vault.Remove(name); err != nil {
+			log.Error("%v", err)
+			os.Exit(1)
+		}
+	default:
+		log.Error("不支持的操作[%v], 只支持add, rotate, remove, list", action)
+		os.Exit(1)
+	}
+
+	if err := vault.Save(); err != nil {
+		log.Error("保存保险箱失败: %v", err)
+		os.Exit(1)
+	}
+	log.Notice("[%v]已经保存, 配置文件里可以写成 \"%v%v\"", name, secret.PREFIX_VAULT, name)
+}
+
+// 状态面板, 不指定管理接口的话直接读Redis
+func _runStatus(args []string) {
+	flags := _newFlagSet("epic status", "[-admin http://127.0.0.1:9898 -admin-token xxx] [-interval 2s] [-events 15]")
+	adminURL := flags.String("admin", "", "运行中的管理接口地址, 比如 'http://127.0.0.1:9898', 为空则读取Redis")
+	adminToken := flags.String("admin-token", os.Getenv("WALKR_ADMIN_TOKEN"), "管理接口的Token, 默认读取环境变量WALKR_ADMIN_TOKEN")
+	interval := flags.Duration("interval", 2*time.Second, "刷新间隔")
+	events := flags.Int("events", 15, "显示最近多少条事件")
+	flags.Parse(args)
+
+	var source dashboard.Source = &dashboard.RedisSource{Redis: redis}
+	if *adminURL != "" {
+		source = &dashboard.AdminSource{URL: *adminURL, Token: *adminToken}
+	}
+	dashboard.Run(source, *interval, *events)
+}
+
+// 查询帮飞记录
+func _runHistory(args []string) {
+	flags := _newFlagSet("epic history", "[-account 账号] [-captain 舰长] [-epic 传说] [-from 日期] [-to 日期] [-format table|csv|json]")
+	account := flags.String("account", "", "账号名称或者PlayerId")
+	captain := flags.String("captain", "", "舰长名称")
+	epic := flags.String("epic", "", "传说ID或者名称")
+	from := flags.String("from", "", "开始日期, 格式 2006-01-02 或 2006-01-02 15:04")
+	to := flags.String("to", "", "结束日期(包含), 格式同上")
+	format := flags.String("format", "table", "输出格式: table, csv, json")
+	flags.Parse(args)
+
+	filter := history.Filter{Account: *account, Captain: *captain, Epic: *epic}
+	var err error
+	if filter.From, err = _parseHistoryTime(*from, false); err != nil {
+		log.Error("开始日期有问题: %v", err)
+		return
+	}
+	if filter.To, err = _parseHistoryTime(*to, true); err != nil {
+		log.Error("结束日期有问题: %v", err)
+		return
+	}
+
+	records, err := history.Query(redis, filter)
+	if err != nil {
+		log.Error("查询帮飞记录失败: %v", err)
+		return
+	}
+	if err := history.Write(os.Stdout, *format, records); err != nil {
+		log.Error("输出帮飞记录失败: %v", err)
+	}
+}
+
+// 只有日期的话, 结束日期算到当天结束
+func _parseHistoryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err == nil && end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, err
+}
+
+type Fleets []Fleet
+
+func (ms Fleets) Len() int {
+	return len(ms)
+}
+
+func (ms Fleets) Less(i, j int) bool {
+	return ms[i].Quality < ms[j].Quality
+}
+
+func (ms Fleets) Swap(i, j int) {
+	ms[i], ms[j] = ms[j], ms[i]
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"game"
+	"gamedb"
+	"os"
+	"snapshots"
+	"time"
+)
+
+// 把游戏数据导入SQLite: walkr game export -db game.db -account 账号 文件...
+// 或者导入保存的所有游戏数据: walkr game export -db game.db -store file|redis [-account 账号]
+func _runGameExport(args []string) {
+	flags := _newFlagSet("game export", "-db game.db [-account 账号] [-at 时间] [-store file|redis] [-dir snapshots] [文件...]")
+	path := flags.String("db", "./game.db", "SQLite数据库文件")
+	account := flags.String("account", "", "账号, 导入文件的时候必须指定")
+	at := flags.String("at", "", "游戏数据的时间, 格式是2006-01-02 15:04:05, 默认是游戏数据里最晚的喂食或者收获时间")
+	kind := flags.String("store", "", "导入walkr game archive保存的游戏数据: file, redis")
+	dir := flags.String("dir", "./snapshots", "file的话保存的目录")
+	flags.Parse(args)
+
+	var store snapshots.Store
+	switch *kind {
+	case "":
+		if *account == "" || flags.NArg() == 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		// 每个文件的时间不一样, 不能都用同一个-at
+		if *at != "" && flags.NArg() > 1 {
+			log.Error("-at只能导入一个文件")
+			os.Exit(2)
+		}
+	case "file":
+		store = snapshots.NewFileStore(*dir)
+	case "redis":
+		store = snapshots.NewRedisStore(redis)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	db, err := gamedb.Open(*path)
+	if err != nil {
+		log.Error("打开[%v]失败: %v", *path, err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if store != nil {
+		err = _exportArchive(db, store, *account)
+	} else {
+		err = _exportFiles(db, *account, *at, flags.Args())
+	}
+	if err != nil {
+		log.Error("%v", err)
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func _exportFiles(db *gamedb.DB, account string, at string, files []string) error {
+	for _, file := range files {
+		result, err := game.Load(file)
+		if err != nil {
+			return err
+		}
+		snapshotAt, err := _snapshotTime(&result.Data, at)
+		if err != nil {
+			return err
+		}
+		if err := _exportSnapshot(db, account, snapshotAt, &result.Data, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _exportArchive(db *gamedb.DB, store snapshots.Store, account string) error {
+	accounts := []string{account}
+	if account == "" {
+		var err error
+		if accounts, err = store.Accounts(); err != nil {
+			return err
+		}
+	}
+
+	for _, account := range accounts {
+		list, err := store.List(account)
+		if err != nil {
+			return err
+		}
+		for _, snapshot := range list {
+			data, err := snapshots.LoadData(store, snapshot)
+			if err != nil {
+				return err
+			}
+			if err := _exportSnapshot(db, account, snapshot.At, data, snapshot.Hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func _exportSnapshot(db *gamedb.DB, account string, at time.Time, data *game.GameData, source string) error {
+	replaced, err := db.Export(account, at, data)
+	if err != nil {
+		return fmt.Errorf("导入[%v]失败: %v", source, err)
+	}
+	action := "导入"
+	if replaced {
+		action = "替换"
+	}
+	log.Notice("[%v]%v为%v %v", source, action, account, at.Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"api"
+	"time"
+
+	goerrors "github.com/go-errors/errors"
+)
+
+var FriendsRoundDuration = 2 * time.Minute
+
+func _friendsRound(playerInfos []api.PlayerInfo, currentRound int) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg := goerrors.Wrap(r, 2).ErrorStack()
+			log.Error("程序挂了: %v", msg)
+		}
+	}()
+
+	for _, playerInfo := range playerInfos {
+		playerInfo.Log().Warning("===================== 第%v次循环 =====================", currentRound)
+
+		api.CheckFriendInvitation(playerInfo)
+	}
+}
+
+// 定时通过所有账号的好友申请: walkr friends
+func runFriends(args []string) {
+	flags := _newFlagSet("friends", "")
+	flags.Parse(args)
+
+	conf, err := _loadConfig()
+	if err != nil {
+		log.Error("%v", err)
+		return
+	}
+
+	for currentRound := 1; ; currentRound++ {
+		_friendsRound(conf.PlayerInfo, currentRound)
+		time.Sleep(FriendsRoundDuration)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"game"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// 游戏数据相关的工具: walkr game reorder|roundtrip|report|diff|map|validate|timing|archive|export|progress [参数]
+func runGame(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "reorder":
+			_runGameReorder(args[1:])
+			return
+		case "roundtrip":
+			_runGameRoundtrip(args[1:])
+			return
+		case "report":
+			_runGameReport(args[1:])
+			return
+		case "diff":
+			_runGameDiff(args[1:])
+			return
+		case "map":
+			_runGameMap(args[1:])
+			return
+		case "validate":
+			_runGameValidate(args[1:])
+			return
+		case "timing":
+			_runGameTiming(args[1:])
+			return
+		case "archive":
+			_runGameArchive(args[1:])
+			return
+		case "export":
+			_runGameExport(args[1:])
+			return
+		case "progress":
+			_runGameProgress(args[1:])
+			return
+		}
+	}
+
+	// 不带操作的时候和原来一样调整星球的顺序
+	_runGameReorder(args)
+}
+
+// 检查游戏数据解析之后再序列化是否和原文件一样: walkr game roundtrip game.json out.json
+func _runGameRoundtrip(args []string) {
+	flags := _newFlagSet("game roundtrip", "[文件...]")
+	flags.Parse(args)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"./game.json"}
+	}
+
+	failed := false
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = game.CheckRoundtrip(data)
+		}
+		if err != nil {
+			log.Error("[%v]没有通过: %v", file, err)
+			failed = true
+			continue
+		}
+		log.Notice("[%v]通过", file)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// 账号的资源、星球、卫星、任务和成就: walkr game report [-in game.json] [-format table|json]
+func _runGameReport(args []string) {
+	flags := _newFlagSet("game report", "[-in game.json] [-format table|json]")
+	in := flags.String("in", "./game.json", "游戏数据文件")
+	format := flags.String("format", "table", "输出格式: table, json")
+	flags.Parse(args)
+
+	result, err := game.Load(*in)
+	if err != nil {
+		log.Error("读取游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+	if err := game.WriteReport(os.Stdout, *format, game.NewReport(&result.Data)); err != nil {
+		log.Error("输出报告失败: %v", err)
+		os.Exit(1)
+	}
+}
+
+// 比较两份游戏数据: walkr game diff [-format summary|json] game.json out.json
+func _runGameDiff(args []string) {
+	flags := _newFlagSet("game diff", "[-format summary|json] 旧文件 新文件")
+	format := flags.String("format", "summary", "输出格式: summary, json")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	before, err := game.Load(flags.Arg(0))
+	if err != nil {
+		log.Error("读取游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+	after, err := game.Load(flags.Arg(1))
+	if err != nil {
+		log.Error("读取游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+	if err := game.WriteDiff(os.Stdout, *format, game.Diff(&before.Data, &after.Data)); err != nil {
+		log.Error("输出比较结果失败: %v", err)
+		os.Exit(1)
+	}
+}
+
+// 画星系图, 两个文件的话并排显示: walkr game map [-format ascii|svg] [-out 文件] game.json [out.json]
+func _runGameMap(args []string) {
+	options := game.DefaultMapOptions()
+	flags := _newFlagSet("game map", "[-format ascii|svg] [-out 文件] [-width 21] [-step 1.5] [-color] [文件...]")
+	format := flags.String("format", "ascii", "输出格式: ascii, svg")
+	out := flags.String("out", "", "输出文件, 默认输出到终端")
+	flags.IntVar(&options.Width, "width", options.Width, "ASCII图的宽度")
+	flags.Float64Var(&options.Step, "step", options.Step, "ASCII图每一行对应的offset_y")
+	flags.BoolVar(&options.Color, "color", false, "ASCII图用颜色区分星球和复制器")
+	flags.Parse(args)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"./game.json"}
+	}
+	snapshots := []*game.GameData{}
+	for _, file := range files {
+		result, err := game.Load(file)
+		if err != nil {
+			log.Error("读取游戏数据失败: %v", err)
+			os.Exit(1)
+		}
+		snapshots = append(snapshots, &result.Data)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Error("创建[%v]失败: %v", *out, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	var err error
+	switch *format {
+	case "ascii":
+		err = game.WriteASCII(w, files, snapshots, options)
+	case "svg":
+		err = game.WriteSVG(w, files, snapshots)
+	default:
+		err = fmt.Errorf("不支持的输出格式[%v]", *format)
+	}
+	if err != nil {
+		log.Error("画星系图失败: %v", err)
+		os.Exit(1)
+	}
+}
+
+// 检查游戏数据是否违反规则, 有问题的话退出码为1: walkr game validate [-rules 规则,...] [-format table|json] [文件...]
+func _runGameValidate(args []string) {
+	flags := _newFlagSet("game validate", "[-rules 规则,...] [-format table|json] [-list] [文件...]")
+	names := flags.String("rules", "", "只检查这些规则, 逗号分开, 默认检查所有规则")
+	format := flags.String("format", "table", "输出格式: table, json")
+	list := flags.Bool("list", false, "列出所有规则")
+	flags.Parse(args)
+
+	if *list {
+		for _, rule := range game.Rules {
+			fmt.Printf("%-28v%v\n", rule.Name, rule.Description)
+		}
+		return
+	}
+
+	rules := []game.Rule{}
+	if *names != "" {
+		for _, name := range strings.Split(*names, ",") {
+			rule, ok := game.FindRule(strings.TrimSpace(name))
+			if !ok {
+				log.Error("没有规则[%v]", name)
+				os.Exit(2)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"./game.json"}
+	}
+
+	failed := false
+	for _, file := range files {
+		result, err := game.Load(file)
+		if err != nil {
+			log.Error("[%v]没有通过: %v", file, err)
+			failed = true
+			continue
+		}
+		violations := game.Validate(&result.Data, rules...)
+		if len(violations) == 0 {
+			log.Notice("[%v]通过", file)
+			continue
+		}
+
+		failed = true
+		log.Error("[%v]有%v个问题", file, len(violations))
+		if err := game.WriteViolations(os.Stdout, *format, violations); err != nil {
+			log.Error("输出检查结果失败: %v", err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// 每个星球多久没有喂食和收获, 以及按小时统计: walkr game timing [-in game.json] [-at 时间|now] [-tz 时区] [-view colonies|hours|all] [-format table|csv]
+func _runGameTiming(args []string) {
+	flags := _newFlagSet("game timing", "[-in game.json] [-at 时间|now] [-tz 时区] [-view colonies|hours|all] [-format table|csv]")
+	in := flags.String("in", "./game.json", "游戏数据文件")
+	at := flags.String("at", "", "计算距今多久的时间, 格式是2006-01-02 15:04:05, now是现在, 默认是游戏数据里最晚的时间")
+	tz := flags.String("tz", "Local", "显示时间和按小时统计用的时区, 比如Asia/Shanghai")
+	view := flags.String("view", game.TIMING_ALL, "输出的内容: colonies, hours, all(csv的话是colonies)")
+	format := flags.String("format", "table", "输出格式: table, csv")
+	flags.Parse(args)
+
+	location, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Error("时区[%v]不对: %v", *tz, err)
+		os.Exit(2)
+	}
+
+	var reference time.Time
+	switch *at {
+	case "":
+	case "now":
+		reference = time.Now()
+	default:
+		reference, err = time.ParseInLocation("2006-01-02 15:04:05", *at, location)
+		if err != nil {
+			log.Error("时间[%v]格式不对: %v", *at, err)
+			os.Exit(2)
+		}
+	}
+
+	result, err := game.Load(*in)
+	if err != nil {
+		log.Error("读取游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+
+	if *format == "csv" && *view == game.TIMING_ALL {
+		*view = game.TIMING_COLONIES
+	}
+	if err := game.WriteTiming(os.Stdout, *format, *view, game.NewTiming(&result.Data, reference, location)); err != nil {
+		log.Error("输出失败: %v", err)
+		os.Exit(1)
+	}
+}
+
+// 调整星球的顺序: walkr game reorder [-in game.json] [-out out.json]
+func _runGameReorder(args []string) {
+	flags := _newFlagSet("game reorder", "[-in game.json] [-out out.json]")
+	in := flags.String("in", "./game.json", "游戏数据文件")
+	out := flags.String("out", "./out.json", "输出文件")
+	flags.Parse(args)
+
+	result, err := game.Load(*in)
+	if err != nil {
+		log.Error("读取游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+
+	result.Data.Reorder()
+	jsonStr, err := json.Marshal(result)
+	if err != nil {
+		log.Error("序列化游戏数据失败: %v", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*out, jsonStr, 0777); err != nil {
+		log.Error("写入[%v]失败: %v", *out, err)
+		os.Exit(1)
+	}
+
+}
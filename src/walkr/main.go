@@ -0,0 +1,136 @@
+package main
+
+import (
+	"config"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	goredis "gopkg.in/redis.v2"
+)
+
+var log = logger.New("walkr", logger.Fields{})
+var redis *goredis.Client
+
+// 全局参数, 放在子命令前面: walkr -c info.toml epic -w 5
+var configFile = flag.String("c", "info.toml", "配置文件名称")
+var logLevel = flag.String("log-level", "", "日志级别, 比如 INFO, 不为空的话覆盖配置文件里的[Log]")
+
+var redisConf = &goredis.Options{
+	Network:      "tcp",
+	Addr:         "localhost:6379",
+	Password:     "",
+	DB:           0,
+	DialTimeout:  5 * time.Second,
+	ReadTimeout:  5 * time.Second,
+	WriteTimeout: 5 * time.Second,
+	PoolSize:     20,
+	IdleTimeout:  60 * time.Second,
+}
+
+// 没有指定子命令的时候执行的命令, 打包给别人用的时候可以设置成proxy:
+//
+//	go build -ldflags "-X main.defaultCommand=proxy" walkr
+var defaultCommand string
+
+type command struct {
+	summary string
+	run     func(args []string)
+}
+
+var commands = map[string]command{
+	"epic":      {"帮飞, 以及 history/status/check/vault", runEpic},
+	"friends":   {"定时通过好友申请", runFriends},
+	"energy":    {"定时转换舰桥能量", runEnergy},
+	"proxy":     {"修改游戏数据的代理", runProxy},
+	"game":      {"调整game.json里星球的顺序", runGame},
+	"maint":     {"维护Redis里的数据", runMaint},
+	"validator": {"代理的验证服务", runValidator},
+}
+
+func main() {
+	flag.StringVar(&redisConf.Addr, "redis", redisConf.Addr, "Redis地址")
+	flag.StringVar(&redisConf.Password, "redis-password", os.Getenv("WALKR_REDIS_PASSWORD"), "Redis密码, 默认读取环境变量WALKR_REDIS_PASSWORD")
+	flag.Int64Var(&redisConf.DB, "redis-db", redisConf.DB, "Redis数据库")
+	flag.Usage = _usage
+	flag.Parse()
+
+	// 初始化Log, 需要配置文件的命令读取配置文件之后再按配置重新设置
+	if err := logger.Setup(logger.Config{Level: *logLevel}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	args := flag.Args()
+	if name == "" && defaultCommand != "" {
+		name = defaultCommand
+	} else if len(args) > 0 {
+		args = args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		if name != "" && name != "help" {
+			fmt.Fprintf(os.Stderr, "不支持的命令[%v]\n\n", name)
+		}
+		_usage()
+		os.Exit(2)
+	}
+
+	redis = goredis.NewClient(redisConf)
+	cmd.run(args)
+}
+
+func _usage() {
+	fmt.Fprintln(os.Stderr, "用法: walkr [全局参数] <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "\n命令:")
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %v\t%v\n", name, commands[name].summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(os.Stderr, "\n全局参数:")
+	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\n每个命令的参数: walkr <命令> -h")
+}
+
+// 所有子命令的参数格式和帮助都一样
+func _newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: walkr [全局参数] %v %v\n", name, usage)
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(os.Stderr, "\n参数:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+// 读取-c指定的配置文件, 并且按配置文件重新设置日志
+func _loadConfig() (config.Config, error) {
+	conf, err := config.Load(*configFile)
+	if err != nil {
+		return conf, fmt.Errorf("配置文件[%v]有问题: %v", *configFile, err)
+	}
+	if *logLevel != "" {
+		conf.Log.Level = *logLevel
+	}
+	if err := logger.Setup(conf.Log); err != nil {
+		return conf, fmt.Errorf("日志配置有问题: %v", err)
+	}
+
+	return conf, nil
+}
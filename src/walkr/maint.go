@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maint"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const maintUsage = "[-dry-run] rounds|fleet-times|sizes|export|import|migrate [参数]"
+
+// 维护Redis里的数据: walkr maint [-dry-run] <操作> [参数]
+func runMaint(args []string) {
+	flags := _newFlagSet("maint", maintUsage+`
+
+操作:
+  rounds       重置轮数(epic:round, energy:round)
+  fleet-times  删除帮飞次数少的舰队计数, 或者清空epic:{player}:fleet:times
+  sizes        列出Key的类型、长度和过期时间
+  export       导出所有状态到JSON
+  import       从JSON导入状态, 文件里的Key会被整个替换
+  migrate      执行还没有执行的迁移, 版本记录在`+maint.VersionKey)
+	dryRun := flags.Bool("dry-run", false, "只列出会做哪些修改, 不修改Redis")
+	flags.Parse(args)
+
+	m := maint.New(redis)
+	m.DryRun = *dryRun
+	m.MaxJoinedTimes = MaxJoinedTimes
+
+	action := flags.Arg(0)
+	actionArgs := []string{}
+	if flags.NArg() > 1 {
+		actionArgs = flags.Args()[1:]
+	}
+
+	var err error
+	switch action {
+	case "rounds":
+		err = _maintRounds(m, actionArgs)
+	case "fleet-times":
+		err = _maintFleetTimes(m, actionArgs)
+	case "sizes":
+		err = _maintSizes(m, actionArgs)
+	case "export":
+		err = _maintExport(m, actionArgs)
+	case "import":
+		err = _maintImport(m, actionArgs)
+	case "migrate":
+		err = _maintMigrate(m, actionArgs)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	_writeChanges(os.Stdout, m)
+	if err != nil {
+		log.Error("%v", err)
+		os.Exit(1)
+	}
+}
+
+func _maintRounds(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint rounds", "[-kind epic|energy|all] [-account 账号,...]")
+	kind := flags.String("kind", maint.ROUNDS_ALL, "重置哪种轮数: epic, energy, all")
+	account := flags.String("account", "", "账号名称或者PlayerId, 多个用逗号分开, 为空表示所有账号")
+	flags.Parse(args)
+
+	playerIds, err := _parseAccounts(*account)
+	if err != nil {
+		return err
+	}
+	return m.ResetRounds(*kind, playerIds)
+}
+
+func _maintFleetTimes(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint fleet-times", "[-below 5 | -reset [-blacklist]] [-account 账号,...]")
+	below := flags.Int("below", MaxJoinedTimes, "删除帮飞次数少于这个值的舰队计数")
+	reset := flags.Bool("reset", false, "清空所有舰队计数")
+	blacklist := flags.Bool("blacklist", false, "和-reset一起用, 同时清空舰队黑名单")
+	account := flags.String("account", "", "账号名称或者PlayerId, 多个用逗号分开, 为空表示所有账号")
+	flags.Parse(args)
+
+	playerIds, err := _parseAccounts(*account)
+	if err != nil {
+		return err
+	}
+	if *reset {
+		return m.ResetFleetTimes(playerIds, *blacklist)
+	}
+	if *blacklist {
+		return fmt.Errorf("-blacklist需要和-reset一起用")
+	}
+	return m.PruneFleetTimes(playerIds, *below)
+}
+
+func _maintSizes(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint sizes", "[-pattern epic:*,quota:*]")
+	pattern := flags.String("pattern", strings.Join(maint.Patterns, ","), "Key的匹配规则, 多个用逗号分开")
+	flags.Parse(args)
+
+	sizes, err := m.Sizes(strings.Split(*pattern, ","))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Key\t类型\t长度\t过期时间")
+	for _, size := range sizes {
+		ttl := "-"
+		if size.TTL > 0 {
+			ttl = size.TTL.String()
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", size.Key, size.Type, size.Length, ttl)
+	}
+	return tw.Flush()
+}
+
+func _maintExport(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint export", "[-out state.json] [-pattern epic:*,quota:*]")
+	out := flags.String("out", "-", "输出文件, -表示标准输出")
+	pattern := flags.String("pattern", strings.Join(maint.Patterns, ","), "Key的匹配规则, 多个用逗号分开")
+	flags.Parse(args)
+
+	if *out == "-" {
+		return m.Export(os.Stdout, strings.Split(*pattern, ","))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := m.Export(f, strings.Split(*pattern, ",")); err != nil {
+		return err
+	}
+	log.Notice("已经导出到[%v]", *out)
+	return nil
+}
+
+func _maintImport(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint import", "[-in state.json]")
+	in := flags.String("in", "-", "导出的文件, -表示标准输入")
+	flags.Parse(args)
+
+	if *in == "-" {
+		return m.Import(os.Stdin)
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.Import(f)
+}
+
+func _maintMigrate(m *maint.Maint, args []string) error {
+	flags := _newFlagSet("maint migrate", "[-to 版本] [-list]")
+	to := flags.Int("to", 0, "执行到哪个版本为止, 0表示最新版本")
+	list := flags.Bool("list", false, "只列出所有迁移和当前版本")
+	flags.Parse(args)
+
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "版本\t状态\t说明")
+		for _, migration := range maint.Migrations {
+			state := "未执行"
+			if migration.Version <= version {
+				state = "已执行"
+			}
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", migration.Version, state, migration.Description)
+		}
+		return tw.Flush()
+	}
+
+	applied, err := m.Migrate(*to)
+	for _, migration := range applied {
+		log.Notice("迁移[%v]完成: %v", migration.Version, migration.Description)
+	}
+	if err == nil && len(applied) == 0 {
+		log.Notice("当前版本[%v]已经是最新的", version)
+	}
+	return err
+}
+
+// 列出执行了(或者-dry-run的时候将要执行)的修改
+func _writeChanges(w io.Writer, m *maint.Maint) {
+	changes := m.Changes()
+	if len(changes) == 0 {
+		return
+	}
+
+	prefix := ""
+	if m.DryRun {
+		prefix = "[dry-run] "
+	}
+	for _, change := range changes {
+		fmt.Fprintf(w, "%v%v\n", prefix, change)
+	}
+	fmt.Fprintf(w, "%v共%v个修改\n", prefix, len(changes))
+}
+
+// 账号可以写PlayerId或者配置文件里的名称, 用到名称的时候才读取配置文件
+func _parseAccounts(value string) ([]int, error) {
+	playerIds := []int{}
+	if value == "" {
+		return playerIds, nil
+	}
+
+	names := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if playerId, err := strconv.Atoi(part); err == nil {
+			playerIds = append(playerIds, playerId)
+			continue
+		}
+
+		if len(names) == 0 {
+			conf, err := _loadConfig()
+			if err != nil {
+				return nil, err
+			}
+			for _, info := range conf.PlayerInfo {
+				names[info.Name] = info.PlayerId()
+			}
+		}
+		playerId, ok := names[part]
+		if !ok {
+			return nil, fmt.Errorf("配置文件里没有账号[%v]", part)
+		}
+		playerIds = append(playerIds, playerId)
+	}
+
+	return playerIds, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"game"
+	"io/ioutil"
+	"os"
+	"snapshots"
+	"text/tabwriter"
+	"time"
+)
+
+const archiveUsage = "[-store file|redis] [-dir snapshots] save|list|series [参数]"
+
+// 按账号保存游戏数据, 看数值的变化: walkr game archive [-store file|redis] <操作> [参数]
+func _runGameArchive(args []string) {
+	flags := _newFlagSet("game archive", archiveUsage+`
+
+操作:
+  save    保存游戏数据, 内容一样的只保存一次
+  list    列出保存的游戏数据
+  series  输出金币、能量块、能量、人口、等级和星球数量随时间的变化, CSV或者HTML图表`)
+	kind := flags.String("store", "file", "保存到哪里: file, redis("+snapshots.AccountsKey+"等)")
+	dir := flags.String("dir", "./snapshots", "file的话保存的目录")
+	flags.Parse(args)
+
+	var store snapshots.Store
+	switch *kind {
+	case "file":
+		store = snapshots.NewFileStore(*dir)
+	case "redis":
+		store = snapshots.NewRedisStore(redis)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	action := flags.Arg(0)
+	actionArgs := []string{}
+	if flags.NArg() > 1 {
+		actionArgs = flags.Args()[1:]
+	}
+
+	var err error
+	switch action {
+	case "save":
+		err = _archiveSave(store, actionArgs)
+	case "list":
+		err = _archiveList(store, actionArgs)
+	case "series":
+		err = _archiveSeries(store, actionArgs)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Error("%v", err)
+		os.Exit(1)
+	}
+}
+
+func _archiveSave(store snapshots.Store, args []string) error {
+	flags := _newFlagSet("game archive save", "-account 账号 [-at 时间] 文件...")
+	account := flags.String("account", "", "账号")
+	at := flags.String("at", "", "游戏数据的时间, 格式是2006-01-02 15:04:05, 默认是游戏数据里最晚的喂食或者收获时间")
+	flags.Parse(args)
+
+	if *account == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	for _, file := range flags.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		result, err := game.Parse(data)
+		if err != nil {
+			return fmt.Errorf("解析[%v]失败: %v", file, err)
+		}
+		snapshotAt, err := _snapshotTime(&result.Data, *at)
+		if err != nil {
+			return err
+		}
+
+		saved, err := store.Save(*account, snapshotAt, data)
+		if err != nil {
+			return fmt.Errorf("保存[%v]失败: %v", file, err)
+		}
+		if saved {
+			log.Notice("[%v]保存为%v %v", file, *account, snapshotAt.Local().Format("2006-01-02 15:04:05"))
+		} else {
+			log.Notice("[%v]和已经保存的游戏数据一样, 跳过", file)
+		}
+	}
+	return nil
+}
+
+// 没有指定时间的话用游戏数据里最晚的喂食或者收获时间, 都没有的话用现在
+func _snapshotTime(data *game.GameData, at string) (time.Time, error) {
+	if at != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", at, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("时间[%v]格式不对: %v", at, err)
+		}
+		return t, nil
+	}
+	if latest := data.LatestTime(); !latest.IsZero() {
+		return latest, nil
+	}
+	return time.Now(), nil
+}
+
+func _archiveList(store snapshots.Store, args []string) error {
+	flags := _newFlagSet("game archive list", "[-account 账号]")
+	account := flags.String("account", "", "账号, 默认列出所有账号")
+	flags.Parse(args)
+
+	accounts := []string{*account}
+	if *account == "" {
+		var err error
+		if accounts, err = store.Accounts(); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "账号\t时间\tsha1")
+	for _, account := range accounts {
+		snapshots, err := store.List(account)
+		if err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(tw, "%v\t%v\t%v\n", snapshot.Account, snapshot.At.Local().Format("2006-01-02 15:04:05"), snapshot.Hash)
+		}
+	}
+	return tw.Flush()
+}
+
+func _archiveSeries(store snapshots.Store, args []string) error {
+	flags := _newFlagSet("game archive series", "-account 账号 [-format csv|html] [-out 文件]")
+	account := flags.String("account", "", "账号")
+	format := flags.String("format", "csv", "输出格式: csv, html")
+	out := flags.String("out", "", "输出文件, 默认输出到终端")
+	flags.Parse(args)
+
+	if *account == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	points, err := snapshots.Series(store, *account)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "csv":
+		return snapshots.WriteCSV(w, points)
+	case "html":
+		return snapshots.WriteHTML(w, *account, points)
+	}
+	return fmt.Errorf("不支持的输出格式[%v]", *format)
+}
+
+// 比较保存的游戏数据, 看成就进度和停滞的任务: walkr game progress [-store file|redis] [-account 账号] [-window 0] [-stall 72h]
+func _runGameProgress(args []string) {
+	flags := _newFlagSet("game progress", "[-store file|redis] [-dir snapshots] [-account 账号] [-window 0] [-stall 72h] [-format table|json]")
+	kind := flags.String("store", "file", "游戏数据保存在哪里: file, redis")
+	dir := flags.String("dir", "./snapshots", "file的话保存的目录")
+	account := flags.String("account", "", "账号, 默认所有账号")
+	window := flags.Int("window", 0, "只看最近几份游戏数据, 0是全部")
+	stall := flags.Duration("stall", 72*time.Hour, "进行中的任务超过这么久没有变化算停滞")
+	format := flags.String("format", "table", "输出格式: table, json")
+	flags.Parse(args)
+
+	var store snapshots.Store
+	switch *kind {
+	case "file":
+		store = snapshots.NewFileStore(*dir)
+	case "redis":
+		store = snapshots.NewRedisStore(redis)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	accounts := []string{*account}
+	if *account == "" {
+		var err error
+		if accounts, err = store.Accounts(); err != nil {
+			log.Error("读取账号失败: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	progresses := []snapshots.Progress{}
+	for _, account := range accounts {
+		progress, err := snapshots.Track(store, account, *window, *stall)
+		if err != nil {
+			log.Error("读取[%v]的游戏数据失败: %v", account, err)
+			os.Exit(1)
+		}
+		progresses = append(progresses, progress)
+	}
+	if err := snapshots.WriteProgress(os.Stdout, *format, progresses); err != nil {
+		log.Error("输出失败: %v", err)
+		os.Exit(1)
+	}
+}
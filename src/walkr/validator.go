@@ -4,34 +4,12 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"net/http"
-	"os"
-	"time"
 
-	"github.com/op/go-logging"
 	goredis "gopkg.in/redis.v2"
 )
 
 const ValidVersion = "2"
 
-var log = logging.MustGetLogger("Walkr")
-var format = logging.MustStringFormatter(
-	"%{color}%{time:15:04:05.000} %{shortfile} ▶ %{level:.4s} %{id:03x}%{color:reset} %{message}",
-)
-
-var redis *goredis.Client
-
-var redisConf = &goredis.Options{
-	Network:      "tcp",
-	Addr:         "localhost:6379",
-	Password:     "",
-	DB:           0,
-	DialTimeout:  5 * time.Second,
-	ReadTimeout:  5 * time.Second,
-	WriteTimeout: 5 * time.Second,
-	PoolSize:     20,
-	IdleTimeout:  60 * time.Second,
-}
-
 func verifyResponse(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	userName := r.FormValue("u")
@@ -75,17 +53,16 @@ func verifyResponse(w http.ResponseWriter, r *http.Request) {
 	return
 
 }
-func main() {
-	// 初始化Log
-	stdOutput := logging.NewLogBackend(os.Stderr, "", 0)
-	stdOutputFormatter := logging.NewBackendFormatter(stdOutput, format)
 
-	logging.SetBackend(stdOutputFormatter)
-	redis = goredis.NewClient(redisConf)
+// 代理启动时的验证服务: walkr validator [-addr :9896]
+func runValidator(args []string) {
+	flags := _newFlagSet("validator", "[-addr :9896]")
+	addr := flags.String("addr", ":9896", "监听地址")
+	flags.Parse(args)
 
 	http.HandleFunc("/verify", verifyResponse)
-	err := http.ListenAndServe(":9896", nil)
+	err := http.ListenAndServe(*addr, nil)
 	if err != nil {
-		log.Fatal("ListenAndServe:", err)
+		log.Fatal("ListenAndServe: %v", err)
 	}
 }